@@ -94,6 +94,31 @@ const (
 	listfiles
 	downloadfile
 	uploadfile
+	export
+	diffparameter
+	checkfdt
+	difffdt
+	dropfields
+	addcontainer
+	removecontainer
+	capacity
+	spacereport
+	applyparameter
+	snapshot
+	parameterhistory
+	parameterrestore
+	feofclog
+	feofplog
+	feofelog
+	rollingrestart
+	userdetails
+	stopuser
+	transactions
+	locks
+	profile
+	commandhistory
+	pruneucb
+	drain
 )
 
 const (
@@ -118,12 +143,12 @@ var displayName = []displayInfo{
 	displayInfo{id: cancel, cmdShort: "cancel", cmdDescription: "Cancel Adabas database"},
 	displayInfo{id: abort, cmdShort: "abort", cmdDescription: "Abort Adabas database"},
 	displayInfo{id: info, cmdShort: "info", cmdDescription: "Retrieve Adabas database information"},
-	displayInfo{id: userqueue, cmdShort: "userqueue", cmdDescription: "Display current user queue"},
+	displayInfo{id: userqueue, cmdShort: "userqueue", cmdDescription: "Display current user queue, param filter like user=ADM*,idle=600,open=true,file=11"},
 	displayInfo{id: cmdqueue, cmdShort: "cmdqueue", cmdDescription: "Display current command queue"},
 	displayInfo{id: holdqueue, cmdShort: "holdqueue", cmdDescription: "Display current hold queue"},
 	displayInfo{id: highwater, cmdShort: "highwater", cmdDescription: "Display high water mark"},
 	displayInfo{id: commandstats, cmdShort: "commandstats", cmdDescription: "Display Adabas command statistics"},
-	displayInfo{id: bp, cmdShort: "bp", cmdDescription: "Display Adabas buffer pool statistics"},
+	displayInfo{id: bp, cmdShort: "bp", cmdDescription: "Display Adabas buffer pool statistics and LBP advice, param target=<hit rate>"},
 	displayInfo{id: activity, cmdShort: "activity", cmdDescription: "Display Adabas activity"},
 	displayInfo{id: threadtable, cmdShort: "threadtable", cmdDescription: "Display Adabas thread table"},
 	displayInfo{id: createdatabase, cmdShort: "createdatabase", cmdDescription: "Create new Adabas database"},
@@ -157,7 +182,32 @@ var displayName = []displayInfo{
 	displayInfo{id: filelocations, cmdShort: "filelocations", cmdDescription: "List all available file locations"},
 	displayInfo{id: listfiles, cmdShort: "listfiles", cmdDescription: "List file in file location"},
 	displayInfo{id: downloadfile, cmdShort: "downloadfile", cmdDescription: "Download file out of file location"},
-	displayInfo{id: uploadfile, cmdShort: "uploadfile", cmdDescription: "Upload file to file location"}}
+	displayInfo{id: uploadfile, cmdShort: "uploadfile", cmdDescription: "Upload file to file location"},
+	displayInfo{id: export, cmdShort: "export", cmdDescription: "Export database definition, files and jobs into the directory given by parameter"},
+	displayInfo{id: diffparameter, cmdShort: "diffparameter", cmdDescription: "Compare static and dynamic parameter with the database given by parameter (optional on -url2 server)"},
+	displayInfo{id: checkfdt, cmdShort: "checkfdt", cmdDescription: "Validate FDT source files given by input"},
+	displayInfo{id: difffdt, cmdShort: "difffdt", cmdDescription: "Compare the FDT of the file with fdt:<file> or <dbid>:<fnr> given by parameter and generate the migration"},
+	displayInfo{id: dropfields, cmdShort: "dropfields", cmdDescription: "Drop Adabas fields given as comma separated list by parameter"},
+	displayInfo{id: addcontainer, cmdShort: "addcontainer", cmdDescription: "Add database container defined by parameter type, size, blocksize and path"},
+	displayInfo{id: removecontainer, cmdShort: "removecontainer", cmdDescription: "Remove last unused ASSO or DATA database container given by parameter"},
+	displayInfo{id: capacity, cmdShort: "capacity", cmdDescription: "Sample container and file usage and forecast the container exhaustion, param horizon=<days>,interval=<seconds>,count=<n>"},
+	displayInfo{id: spacereport, cmdShort: "spacereport", cmdDescription: "Report allocated and used blocks of all files and the free space table"},
+	displayInfo{id: applyparameter, cmdShort: "applyparameter", cmdDescription: "Apply YAML/JSON parameter file given by input, rollback with param rollback=true"},
+	displayInfo{id: snapshot, cmdShort: "snapshot", cmdDescription: "Store current parameters in the local parameter history"},
+	displayInfo{id: parameterhistory, cmdShort: "parameterhistory", cmdDescription: "List or compare versions of the local parameter history"},
+	displayInfo{id: parameterrestore, cmdShort: "parameterrestore", cmdDescription: "Restore parameters of a version in the local parameter history"},
+	displayInfo{id: feofclog, cmdShort: "feofclog", cmdDescription: "Switch to a new command log"},
+	displayInfo{id: feofplog, cmdShort: "feofplog", cmdDescription: "Switch to a new protection log, param etsync=true waits for ET synchronization"},
+	displayInfo{id: feofelog, cmdShort: "feofelog", cmdDescription: "Switch to a new extended log"},
+	displayInfo{id: rollingrestart, cmdShort: "rollingrestart", cmdDescription: "Restart the databases given by param one after another, like 24,25,26"},
+	displayInfo{id: userdetails, cmdShort: "userdetails", cmdDescription: "Display user queue entry details of user queue id given by param"},
+	displayInfo{id: stopuser, cmdShort: "stopuser", cmdDescription: "Stop user queue id given by param, or all users matching a filter like user=ADM*,idle=600"},
+	displayInfo{id: transactions, cmdShort: "transactions", cmdDescription: "List users approaching TT or TNA limits, param threshold=<percent>,stop=<percent>"},
+	displayInfo{id: locks, cmdShort: "locks", cmdDescription: "Analyze lock contention of hold queue, user queue and command queue"},
+	displayInfo{id: profile, cmdShort: "profile", cmdDescription: "Sample thread table and command queue, param duration=<seconds>,interval=<seconds>,buckets=<n>"},
+	displayInfo{id: commandhistory, cmdShort: "commandhistory", cmdDescription: "Sample command statistics and report command mix and spikes, param window=<hours>,compare=<hours>,spike=<factor>"},
+	displayInfo{id: pruneucb, cmdShort: "pruneucb", cmdDescription: "Delete Adabas UCB entries matching filter"},
+	displayInfo{id: drain, cmdShort: "drain", cmdDescription: "Wait for open transactions and shutdown Adabas database, param grace=<seconds>,stopidle=true,idle=<seconds>,deadline=<seconds>"}}
 
 func displayValue(name string) display {
 	for i := 0; i < len(displayName); i++ {
@@ -182,6 +232,7 @@ func usage() {
 
 func main() {
 	var restURL string
+	var targetURL string
 	var input database.InputList
 
 	user := flag.String("user", "admin", "User name of the main administrator (default: admin)")
@@ -191,8 +242,11 @@ func main() {
 	sleep := flag.Int("repeat", 0, "Repeat display after given seconds")
 	ignoreTLS := flag.Bool("ignoreTLS", false, "Ignore TLS certificate validation")
 	param := flag.String("param", "", "Method specific parameters")
+	wait := flag.Bool("wait", false, "Wait after start or shutdown until the database is online or offline")
+	timeout := flag.Int("timeout", 300, "Timeout in seconds used by -wait")
 
 	flag.Var(&input, "input", "Input configuration")
+	flag.StringVar(&targetURL, "url2", "", "Second RESTful server location URL used by compare commands (default: same as -url)")
 	flag.StringVar(&restURL, "url", "", "Remote RESTful server location URL, may be predefined using environment variable ADABAS_ADMIN_URL (example: localhost:8120, https://localhost:8121)")
 	flag.Parse()
 
@@ -213,6 +267,10 @@ func main() {
 	password := *passwd
 
 	printStart(restURL, username)
+	if targetURL != "" {
+		fmt.Println(time.Now().Format("2006/01/02 15:04:05"), "Compare server: "+targetURL)
+		fmt.Println()
+	}
 
 	for _, a := range args {
 		if displayValue(a) == unknown {
@@ -229,82 +287,15 @@ func main() {
 			password = credentials()
 		}
 	}
-	cookieJar, _ := cookiejar.New(nil)
-	ru := restURL
-	if strings.HasPrefix(ru, "http") {
-		ru = ru[strings.Index(ru, "://")+3:]
-	}
-	h, _, errx := net.SplitHostPort(ru)
-	if errx != nil {
-		fmt.Printf("Host url error %s: %v", restURL, errx)
-		os.Exit(2)
-	}
-	cookieURL := &url.URL{Scheme: "http", Host: h, Path: "/adabas"}
-	var cookie *http.Cookie
-	auth := runtime.ClientAuthInfoWriterFunc(func(r runtime.ClientRequest, _ strfmt.Registry) error {
-		//	if cookie == nil {
-		cookies := cookieJar.Cookies(cookieURL)
-		for _, c := range cookies {
-			if c.Name == "ADAADMIN" {
-				expiration := time.Now().Add(5 * time.Minute)
-				cookie = &http.Cookie{Name: "ADAADMIN", Value: c.Value, Expires: expiration}
-				r.SetHeaderParam("Cookie", cookie.String())
-				break
-			}
-		}
-		//	}
-		encoded := base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
-		return r.SetHeaderParam("Authorization", "Basic "+encoded)
-	})
-
-	var transport *httptransport.Runtime
-	if strings.HasPrefix(restURL, "http") {
-		if strings.HasPrefix(restURL, "https") {
-			restURL = restURL[8:]
-			// create the transport
-			transport = httptransport.New(restURL, "", []string{"https"})
-			if *ignoreTLS {
-				transport.Transport.(*http.Transport).TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
-			}
-		} else {
-			restURL = restURL[7:]
-			// create the transport
-			transport = httptransport.New(restURL, "", []string{"http"})
-		}
-
-	} else {
-		// create the transport
-		transport = httptransport.New(restURL, "", []string{"http"})
-	}
-
-	// transport.EnableConnectionReuse()
-	//	transport.Transport = &MyTransport{apiKey: "ADAADMIN", rt: transport.Transport}
-	transport.Jar = cookieJar
-
-	// create the API client, with the transport
-	clientInstance := client.New(transport, strfmt.Default)
+	clientInstance, auth := connect(restURL, username, password, *ignoreTLS, len(args) > 0)
 	if len(args) == 0 {
 		version(clientInstance)
 		return
 	}
-	loginParm := environment.NewGetLoginSessionParams()
-	loginOk, err := clientInstance.Environment.GetLoginSession(loginParm, auth)
-	if err == nil {
-		// Received Bearer JWT token
-		auth = runtime.ClientAuthInfoWriterFunc(func(r runtime.ClientRequest, _ strfmt.Registry) error {
-			cookies := cookieJar.Cookies(cookieURL)
-			for _, c := range cookies {
-				if c.Name == "ADAADMIN" {
-					expiration := time.Now().Add(5 * time.Minute)
-					cookie = &http.Cookie{Name: "ADAADMIN", Value: c.Value, Expires: expiration}
-					r.SetHeaderParam("Cookie", cookie.String())
-					break
-				}
-			}
-			return r.SetHeaderParam("Authorization", "Bearer "+loginOk.Payload.Token)
-		})
-	} else {
-		fmt.Printf("Error to login session: %v\n", err)
+	// Second server used by compare commands, default is the same server
+	targetInstance, targetAuth := clientInstance, auth
+	if targetURL != "" {
+		targetInstance, targetAuth = connect(targetURL, username, password, *ignoreTLS, true)
 	}
 	// expiration := time.Now().Add(5 * time.Minute)
 	// cookie := http.Cookie{Name: "myCookie", Value: "Hello World", Expires: expiration}
@@ -328,8 +319,14 @@ func main() {
 				err = database.List(clientInstance, auth)
 			case start:
 				err = database.Operation(clientInstance, *dbid, "start", auth)
+				if err == nil && *wait {
+					err = database.WaitState(clientInstance, *dbid, true, *timeout, auth)
+				}
 			case shutdown:
 				err = database.Operation(clientInstance, *dbid, "shutdown", auth)
+				if err == nil && *wait {
+					err = database.WaitState(clientInstance, *dbid, false, *timeout, auth)
+				}
 			case cancel:
 				err = database.Operation(clientInstance, *dbid, "cancel", auth)
 			case abort:
@@ -337,7 +334,7 @@ func main() {
 			case info:
 				err = database.Operation(clientInstance, *dbid, "", auth)
 			case userqueue:
-				err = database.UserQueue(clientInstance, *dbid, auth)
+				err = database.UserQueue(clientInstance, *dbid, *param, auth)
 			case cmdqueue:
 				err = database.CommandQueue(clientInstance, *dbid, auth)
 			case holdqueue:
@@ -347,9 +344,9 @@ func main() {
 			case commandstats:
 				err = database.CommandStats(clientInstance, *dbid, auth)
 			case bp:
-				err = database.BufferpoolStats(clientInstance, *dbid, auth)
+				err = database.BufferpoolStats(clientInstance, *dbid, *param, auth)
 			case activity:
-				err = database.Activity(clientInstance, *dbid, auth)
+				err = database.Activity(clientInstance, *dbid, *param, auth)
 			case threadtable:
 				err = database.ThreadTable(clientInstance, *dbid, auth)
 			case createdatabase:
@@ -379,7 +376,7 @@ func main() {
 			case information:
 				err = database.Information(clientInstance, *dbid, auth)
 			case fields:
-				err = database.Fields(clientInstance, *dbid, *fnr, auth)
+				err = database.Fields(clientInstance, *dbid, *fnr, *param, auth)
 			case container:
 				err = database.Container(clientInstance, *dbid, auth)
 			case renamefile:
@@ -401,7 +398,7 @@ func main() {
 			case joblog:
 				err = job.Log(clientInstance, *param, auth)
 			case listucb:
-				err = database.Ucb(clientInstance, *dbid, auth)
+				err = database.Ucb(clientInstance, *dbid, *param, auth)
 			case deleteucb:
 				err = database.DeleteUcb(clientInstance, *dbid, *param, auth)
 			case addfields:
@@ -416,6 +413,59 @@ func main() {
 				err = filebrowser.Download(clientInstance, *param, input.String(), auth)
 			case uploadfile:
 				err = filebrowser.Upload(clientInstance, *param, input.String(), auth)
+			case export:
+				err = database.Export(clientInstance, *dbid, *param, auth)
+			case diffparameter:
+				err = database.DiffParameter(clientInstance, *dbid, targetInstance, *param, auth, targetAuth)
+			case checkfdt:
+				err = database.CheckFdt(input)
+			case difffdt:
+				err = database.DiffFdt(clientInstance, *dbid, *fnr, targetInstance, *param, auth, targetAuth)
+			case dropfields:
+				err = database.DropFields(clientInstance, *dbid, *fnr, *param, auth)
+			case addcontainer:
+				err = database.AddContainer(clientInstance, *dbid, *param, auth)
+			case removecontainer:
+				err = database.RemoveContainer(clientInstance, *dbid, *param, auth)
+			case capacity:
+				err = database.Capacity(clientInstance, *dbid, *param, auth)
+			case spacereport:
+				err = database.SpaceReport(clientInstance, *dbid, auth)
+			case applyparameter:
+				err = database.ApplyParameter(clientInstance, *dbid, input.String(), *param, auth)
+			case snapshot:
+				err = database.Snapshot(clientInstance, *dbid, *param, auth)
+			case parameterhistory:
+				err = database.ParameterHistory(clientInstance, *dbid, *param)
+			case parameterrestore:
+				err = database.ParameterRestore(clientInstance, *dbid, *param, auth)
+			case feofclog:
+				err = database.LogSwitch(clientInstance, *dbid, "feofclog", *param, auth)
+			case feofplog:
+				err = database.LogSwitch(clientInstance, *dbid, "feofplog", *param, auth)
+			case feofelog:
+				err = database.LogSwitch(clientInstance, *dbid, "feofelog", *param, auth)
+			case rollingrestart:
+				err = database.RollingRestart(clientInstance, *dbid, *param, *timeout, auth)
+			case userdetails:
+				err = database.UserDetails(clientInstance, *dbid, *param, auth)
+			case stopuser:
+				err = database.DeleteUser(clientInstance, *dbid, *param, auth)
+			case transactions:
+				err = database.Transactions(clientInstance, *dbid, *param, auth)
+			case locks:
+				err = database.Locks(clientInstance, *dbid, auth)
+			case profile:
+				err = database.Profile(clientInstance, *dbid, *param, auth)
+			case commandhistory:
+				err = database.CommandHistory(clientInstance, *dbid, *param, auth)
+			case pruneucb:
+				err = database.PruneUcb(clientInstance, *dbid, *param, auth)
+			case drain:
+				err = database.DrainShutdown(clientInstance, *dbid, *param, auth)
+				if err == nil && *wait {
+					err = database.WaitState(clientInstance, *dbid, false, *timeout, auth)
+				}
 			default:
 				err = version(clientInstance)
 			}
@@ -423,6 +473,9 @@ func main() {
 				break
 			}
 		}
+		if err == database.ErrWaitTimeout {
+			os.Exit(11)
+		}
 		if err != nil {
 			os.Exit(10)
 		}
@@ -435,6 +488,87 @@ func main() {
 	}
 }
 
+// connect create the RESTful client of the given server location and login the session
+func connect(restURL string, username string, password string, ignoreTLS bool, login bool) (*client.AdabasAdmin, runtime.ClientAuthInfoWriter) {
+	cookieJar, _ := cookiejar.New(nil)
+	ru := restURL
+	if strings.HasPrefix(ru, "http") {
+		ru = ru[strings.Index(ru, "://")+3:]
+	}
+	h, _, errx := net.SplitHostPort(ru)
+	if errx != nil {
+		fmt.Printf("Host url error %s: %v", restURL, errx)
+		os.Exit(2)
+	}
+	cookieURL := &url.URL{Scheme: "http", Host: h, Path: "/adabas"}
+	var cookie *http.Cookie
+	auth := runtime.ClientAuthInfoWriterFunc(func(r runtime.ClientRequest, _ strfmt.Registry) error {
+		//	if cookie == nil {
+		cookies := cookieJar.Cookies(cookieURL)
+		for _, c := range cookies {
+			if c.Name == "ADAADMIN" {
+				expiration := time.Now().Add(5 * time.Minute)
+				cookie = &http.Cookie{Name: "ADAADMIN", Value: c.Value, Expires: expiration}
+				r.SetHeaderParam("Cookie", cookie.String())
+				break
+			}
+		}
+		//	}
+		encoded := base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+		return r.SetHeaderParam("Authorization", "Basic "+encoded)
+	})
+
+	var transport *httptransport.Runtime
+	if strings.HasPrefix(restURL, "http") {
+		if strings.HasPrefix(restURL, "https") {
+			restURL = restURL[8:]
+			// create the transport
+			transport = httptransport.New(restURL, "", []string{"https"})
+			if ignoreTLS {
+				transport.Transport.(*http.Transport).TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+			}
+		} else {
+			restURL = restURL[7:]
+			// create the transport
+			transport = httptransport.New(restURL, "", []string{"http"})
+		}
+
+	} else {
+		// create the transport
+		transport = httptransport.New(restURL, "", []string{"http"})
+	}
+
+	// transport.EnableConnectionReuse()
+	//	transport.Transport = &MyTransport{apiKey: "ADAADMIN", rt: transport.Transport}
+	transport.Jar = cookieJar
+
+	// create the API client, with the transport
+	clientInstance := client.New(transport, strfmt.Default)
+	if !login {
+		return clientInstance, auth
+	}
+	loginParm := environment.NewGetLoginSessionParams()
+	loginOk, err := clientInstance.Environment.GetLoginSession(loginParm, auth)
+	if err == nil {
+		// Received Bearer JWT token
+		auth = runtime.ClientAuthInfoWriterFunc(func(r runtime.ClientRequest, _ strfmt.Registry) error {
+			cookies := cookieJar.Cookies(cookieURL)
+			for _, c := range cookies {
+				if c.Name == "ADAADMIN" {
+					expiration := time.Now().Add(5 * time.Minute)
+					cookie = &http.Cookie{Name: "ADAADMIN", Value: c.Value, Expires: expiration}
+					r.SetHeaderParam("Cookie", cookie.String())
+					break
+				}
+			}
+			return r.SetHeaderParam("Authorization", "Bearer "+loginOk.Payload.Token)
+		})
+	} else {
+		fmt.Printf("Error to login session: %v\n", err)
+	}
+	return clientInstance, auth
+}
+
 func printStart(location string, username string) {
 	out := "2006/01/02 15:04:05"
 
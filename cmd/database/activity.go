@@ -0,0 +1,188 @@
+/*
+* Copyright © 2018 Software AG, Darmstadt, Germany and/or its licensors
+*
+* SPDX-License-Identifier: Apache-2.0
+*
+*   Licensed under the Apache License, Version 2.0 (the "License");
+*   you may not use this file except in compliance with the License.
+*   You may obtain a copy of the License at
+*
+*       http://www.apache.org/licenses/LICENSE-2.0
+*
+*   Unless required by applicable law or agreed to in writing, software
+*   distributed under the License is distributed on an "AS IS" BASIS,
+*   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*   See the License for the specific language governing permissions and
+*   limitations under the License.
+*
+ */
+
+package database
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-openapi/runtime"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"softwareag.com/client"
+	"softwareag.com/client/online"
+	"softwareag.com/models"
+)
+
+// activityCounter cumulated activity counter with the parameters to check if it rises
+type activityCounter struct {
+	name     string
+	value    func(*models.ActivityStatsStatistics) int64
+	guidance string
+}
+
+// activityCounters throwback and workpool space wait counters attributed per interval
+var activityCounters = []*activityCounter{
+	{"UQ context", func(s *models.ActivityStatsStatistics) int64 { return s.ThbWaitUQContext },
+		"commands wait for the context of their user queue element, check NU and users sending parallel commands"},
+	{"ISN", func(s *models.ActivityStatsStatistics) int64 { return s.ThbWaitIsn },
+		"records are in hold by other users, check NISNHQ and NH and long transactions using the locks command"},
+	{"ET sync", func(s *models.ActivityStatsStatistics) int64 { return s.ThbEtSync },
+		"ET commands wait for the transaction synchronisation, check TT and the transactions command"},
+	{"DWP overflow", func(s *models.ActivityStatsStatistics) int64 { return s.ThbDWPOverflow },
+		"the dirty work pool overflows, increase LWP or shorten long running update transactions"},
+	{"WP space", func(s *models.ActivityStatsStatistics) int64 { return s.WpSpaceWaitTotal },
+		"commands wait for work pool space, increase LWP"},
+}
+
+// activityInterval counter increase between two activity samples
+type activityInterval struct {
+	start   time.Time
+	end     time.Time
+	deltas  []int64
+	current int64
+}
+
+// getActivityStats read the activity statistics of the database
+func getActivityStats(clientInstance *client.AdabasAdmin, dbid int, auth runtime.ClientAuthInfoWriter) (*models.ActivityStatsStatistics, error) {
+	params := online.NewGetDatabaseActStatsParams()
+	params.Dbid = float64(dbid)
+	resp, err := clientInstance.Online.GetDatabaseActStats(params, auth)
+	if err != nil {
+		switch err.(type) {
+		case *online.GetDatabaseActStatsBadRequest:
+			response := err.(*online.GetDatabaseActStatsBadRequest)
+			fmt.Println(response.Payload.Error.Code, ":", response.Payload.Error.Message)
+		default:
+			fmt.Println("Error:", err)
+		}
+		return nil, err
+	}
+	if resp.Payload.Statistics == nil {
+		return nil, fmt.Errorf("No activity statistics available")
+	}
+	return resp.Payload.Statistics, nil
+}
+
+// activityDeltas increase of the activity counters. A counter lower than in the previous sample
+// means a nucleus restart, the counters are taken as they are.
+func activityDeltas(previous, current *models.ActivityStatsStatistics) []int64 {
+	deltas := make([]int64, len(activityCounters))
+	restart := false
+	for i, c := range activityCounters {
+		deltas[i] = c.value(current) - c.value(previous)
+		if deltas[i] < 0 {
+			restart = true
+		}
+	}
+	if restart {
+		for i, c := range activityCounters {
+			deltas[i] = c.value(current)
+		}
+	}
+	return deltas
+}
+
+// activityGuidance guidance for the counters rising in the intervals with total and peak interval
+func activityGuidance(intervals []*activityInterval) []string {
+	guidance := make([]string, 0)
+	for i, c := range activityCounters {
+		total := int64(0)
+		var peak *activityInterval
+		for _, in := range intervals {
+			total += in.deltas[i]
+			if in.deltas[i] > 0 && (peak == nil || in.deltas[i] > peak.deltas[i]) {
+				peak = in
+			}
+		}
+		if total == 0 {
+			continue
+		}
+		guidance = append(guidance, fmt.Sprintf("%s: %d, peak %d at %s, %s", c.name, total, peak.deltas[i],
+			peak.end.Format("15:04:05"), c.guidance))
+	}
+	return guidance
+}
+
+// activityIntervals sample the activity statistics count times every interval and attribute the
+// throwbacks and workpool space waits to the intervals
+func activityIntervals(clientInstance *client.AdabasAdmin, dbid int, param string, auth runtime.ClientAuthInfoWriter) error {
+	values, err := parseKeyValues(param, "interval", "count")
+	if err != nil {
+		fmt.Println(err.Error())
+		return err
+	}
+	settings := map[string]float64{"interval": 60, "count": 5}
+	for k, v := range values {
+		f, perr := strconv.ParseFloat(v, 64)
+		if perr != nil || f <= 0 {
+			fmt.Printf("Parameter %s=%s not valid, need positive number\n", k, v)
+			return fmt.Errorf("Parameter %s=%s not valid", k, v)
+		}
+		settings[k] = f
+	}
+	interval := time.Duration(settings["interval"] * float64(time.Second))
+	count := int(settings["count"])
+	if count < 1 {
+		count = 1
+	}
+
+	p := message.NewPrinter(language.English)
+	fmt.Printf("\nAdabas activity of database %03d, %d intervals of %v\n\n", dbid, count, interval)
+	header := fmt.Sprintf(" %-19s", "Interval end")
+	for _, c := range activityCounters {
+		header += fmt.Sprintf(" %12s", c.name)
+	}
+	fmt.Println(header + fmt.Sprintf(" %12s", "WP waiting"))
+	previous, err := getActivityStats(clientInstance, dbid, auth)
+	if err != nil {
+		return err
+	}
+	start := time.Now()
+	intervals := make([]*activityInterval, 0, count)
+	for i := 0; i < count; i++ {
+		time.Sleep(interval)
+		current, cerr := getActivityStats(clientInstance, dbid, auth)
+		if cerr != nil {
+			return cerr
+		}
+		in := &activityInterval{start: start, end: time.Now(), deltas: activityDeltas(previous, current),
+			current: current.WPSpaceWaitCurrent}
+		intervals = append(intervals, in)
+		line := p.Sprintf(" %-19s", in.end.Format("2006-01-02 15:04:05"))
+		for _, d := range in.deltas {
+			line += p.Sprintf(" %12d", d)
+		}
+		fmt.Println(line + p.Sprintf(" %12d", in.current))
+		previous, start = current, in.end
+	}
+
+	fmt.Println()
+	guidance := activityGuidance(intervals)
+	if len(guidance) == 0 {
+		fmt.Println(" No throwbacks or work pool space waits in the intervals")
+	}
+	for _, g := range guidance {
+		fmt.Println(" " + g)
+	}
+	fmt.Println()
+	return nil
+}
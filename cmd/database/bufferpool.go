@@ -0,0 +1,146 @@
+/*
+* Copyright © 2018 Software AG, Darmstadt, Germany and/or its licensors
+*
+* SPDX-License-Identifier: Apache-2.0
+*
+*   Licensed under the Apache License, Version 2.0 (the "License");
+*   you may not use this file except in compliance with the License.
+*   You may obtain a copy of the License at
+*
+*       http://www.apache.org/licenses/LICENSE-2.0
+*
+*   Unless required by applicable law or agreed to in writing, software
+*   distributed under the License is distributed on an "AS IS" BASIS,
+*   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*   See the License for the specific language governing permissions and
+*   limitations under the License.
+*
+ */
+
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/go-openapi/runtime"
+	"softwareag.com/client"
+	"softwareag.com/client/online"
+	"softwareag.com/models"
+)
+
+// bufferpoolHistory local history of the buffer pool samples
+const bufferpoolHistory = "bufferpool"
+
+// bufferpoolSample buffer pool size and cumulated read counts at a point in time
+type bufferpoolSample struct {
+	Time          time.Time `json:"time"`
+	Size          int64     `json:"size"`
+	Highwater     int64     `json:"highwater"`
+	LogicalReads  int64     `json:"logicalReads"`
+	PhysicalReads int64     `json:"physicalReads"`
+}
+
+// percentOf percentage of the value in the total, zero if the total is zero
+func percentOf(value, total int64) float64 {
+	if total <= 0 {
+		return 0
+	}
+	return float64(value) * 100 / float64(total)
+}
+
+// hitRate buffer pool hit rate of the logical reads not needing a physical read
+func hitRate(logical, physical int64) (float64, bool) {
+	if logical <= 0 {
+		return 0, false
+	}
+	return percentOf(logical-physical, logical), true
+}
+
+// getBufferpoolStats read the buffer pool statistics of the database
+func getBufferpoolStats(clientInstance *client.AdabasAdmin, dbid int, auth runtime.ClientAuthInfoWriter) (*models.BufferPoolStatsStatistics, error) {
+	params := online.NewGetDatabaseBPStatsParams()
+	params.Dbid = float64(dbid)
+	resp, err := clientInstance.Online.GetDatabaseBPStats(params, auth)
+	if err != nil {
+		switch err.(type) {
+		case *online.GetDatabaseBPStatsBadRequest:
+			response := err.(*online.GetDatabaseBPStatsBadRequest)
+			fmt.Println(response.Payload.Error.Code, ":", response.Payload.Error.Message)
+		default:
+			fmt.Println("Error:", err)
+		}
+		return nil, err
+	}
+	if resp.Payload.Statistics == nil {
+		return nil, fmt.Errorf("No buffer pool statistics available")
+	}
+	return resp.Payload.Statistics, nil
+}
+
+// bufferpoolHitRates hit rates between consecutive samples taken with the given buffer pool size,
+// hit rates of other pool sizes are not comparable. The day is relative to the first sample. Lower
+// counts than in the previous sample mean a restart.
+func bufferpoolHitRates(samples []*bufferpoolSample, size int64) []trendPoint {
+	points := make([]trendPoint, 0)
+	for i := 1; i < len(samples); i++ {
+		previous, current := samples[i-1], samples[i]
+		if current.Size != size || previous.Size != size || !current.Time.After(previous.Time) {
+			continue
+		}
+		logical := current.LogicalReads - previous.LogicalReads
+		physical := current.PhysicalReads - previous.PhysicalReads
+		if logical < 0 || physical < 0 {
+			logical, physical = current.LogicalReads, current.PhysicalReads
+		}
+		if rate, ok := hitRate(logical, physical); ok {
+			points = append(points, trendPoint{day: current.Time.Sub(samples[0].Time).Hours() / 24, value: rate})
+		}
+	}
+	return points
+}
+
+// adviseLBP recommend a buffer pool size using the hit rate trend. A hit rate below the target or
+// falling below the target within a week increases the pool, a pool reaching the target without
+// using the high water part may be reduced.
+func adviseLBP(size, highwater int64, points []trendPoint, target float64) (int64, string) {
+	if len(points) < 2 {
+		return size, "not enough samples, collect samples over time using -repeat"
+	}
+	sum := 0.0
+	for _, p := range points {
+		sum += p.value
+	}
+	average := sum / float64(len(points))
+	slope, intercept, _ := linearTrend(points)
+	week := intercept + slope*(points[len(points)-1].day+7)
+	switch {
+	case average < target-5:
+		return size * 2, fmt.Sprintf("average hit rate %.1f%% far below target %.0f%%", average, target)
+	case average < target:
+		return size * 3 / 2, fmt.Sprintf("average hit rate %.1f%% below target %.0f%%", average, target)
+	case week < target:
+		return size * 5 / 4, fmt.Sprintf("hit rate trend %.2f%% per day reaches %.1f%% within a week", slope, week)
+	case highwater > 0 && percentOf(highwater, size) < 60:
+		return highwater * 5 / 4, fmt.Sprintf("hit rate %.1f%% reached with %.0f%% of the pool used", average,
+			percentOf(highwater, size))
+	}
+	return size, fmt.Sprintf("average hit rate %.1f%% meets target %.0f%%", average, target)
+}
+
+// readBufferpoolSamples read the buffer pool samples of the local history
+func readBufferpoolSamples(server string, dbid int) ([]*bufferpoolSample, error) {
+	entries, err := readHistory(bufferpoolHistory, server, dbid)
+	if err != nil {
+		return nil, err
+	}
+	samples := make([]*bufferpoolSample, 0)
+	for _, e := range entries {
+		sample := &bufferpoolSample{}
+		if json.Unmarshal(e, sample) == nil {
+			samples = append(samples, sample)
+		}
+	}
+	return samples, nil
+}
@@ -0,0 +1,408 @@
+/*
+* Copyright © 2018 Software AG, Darmstadt, Germany and/or its licensors
+*
+* SPDX-License-Identifier: Apache-2.0
+*
+*   Licensed under the Apache License, Version 2.0 (the "License");
+*   you may not use this file except in compliance with the License.
+*   You may obtain a copy of the License at
+*
+*       http://www.apache.org/licenses/LICENSE-2.0
+*
+*   Unless required by applicable law or agreed to in writing, software
+*   distributed under the License is distributed on an "AS IS" BASIS,
+*   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*   See the License for the specific language governing permissions and
+*   limitations under the License.
+*
+ */
+
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-openapi/runtime"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"softwareag.com/client"
+	"softwareag.com/client/online_offline"
+	"softwareag.com/models"
+)
+
+// capacityHistory name of the local capacity sample history
+const capacityHistory = "capacity"
+
+// capacitySample capacity sample of the database containers and files. The container size and the
+// free space are kept in bytes, containers of one type may have different block sizes.
+type capacitySample struct {
+	Time       time.Time        `json:"time"`
+	TotalBytes map[string]int64 `json:"totalBytes"`
+	FreeBytes  map[string]int64 `json:"freeBytes"`
+	Files      []capacityFile   `json:"files"`
+	// Blocks, FreeBlocks and BlockSize are only used by samples of earlier versions
+	Blocks     map[string]int64 `json:"total,omitempty"`
+	FreeBlocks map[string]int64 `json:"free,omitempty"`
+	BlockSize  map[string]int64 `json:"blockSize,omitempty"`
+}
+
+// capacityFile used ASSO and DATA blocks and ISN usage of a file
+type capacityFile struct {
+	File   int64  `json:"file"`
+	Name   string `json:"name"`
+	Asso   int64  `json:"asso"`
+	Data   int64  `json:"data"`
+	TopIsn int64  `json:"topIsn"`
+	MaxIsn int64  `json:"maxIsn"`
+}
+
+// trendPoint sample value at the given day
+type trendPoint struct {
+	day   float64
+	value float64
+}
+
+// linearTrend least squares fit of the points, returns the growth per day and the value at day 0
+func linearTrend(points []trendPoint) (slope float64, intercept float64, ok bool) {
+	if len(points) < 2 {
+		return 0, 0, false
+	}
+	n := float64(len(points))
+	var sx, sy, sxx, sxy float64
+	for _, p := range points {
+		sx += p.day
+		sy += p.value
+		sxx += p.day * p.day
+		sxy += p.day * p.value
+	}
+	d := n*sxx - sx*sx
+	if d == 0 {
+		return 0, 0, false
+	}
+	slope = (n*sxy - sx*sy) / d
+	intercept = (sy - slope*sx) / n
+	return slope, intercept, true
+}
+
+// daysUntil days until the trend reach the limit, starting at the given day
+func daysUntil(slope float64, current float64, limit float64) (float64, bool) {
+	if slope <= 0 {
+		return 0, false
+	}
+	if current >= limit {
+		return 0, true
+	}
+	return (limit - current) / slope, true
+}
+
+// getFiles read the list of database files
+func getFiles(clientInstance *client.AdabasAdmin, dbid int, auth runtime.ClientAuthInfoWriter) ([]*models.FileInfo, error) {
+	params := online_offline.NewGetDatabaseFilesParams()
+	params.Dbid = float64(dbid)
+	resp, err := clientInstance.OnlineOffline.GetDatabaseFiles(params, auth)
+	if err != nil {
+		switch err.(type) {
+		case *online_offline.GetDatabaseFilesBadRequest:
+			response := err.(*online_offline.GetDatabaseFilesBadRequest)
+			fmt.Println(response.Payload.Error.Code, ":", response.Payload.Error.Message)
+		default:
+			fmt.Println("Error:", err)
+		}
+		return nil, err
+	}
+	return resp.Payload.Files, nil
+}
+
+// getFcb read the FCB of the database file
+func getFcb(clientInstance *client.AdabasAdmin, dbid int, fnr int64, auth runtime.ClientAuthInfoWriter) (*models.FcbFile, error) {
+	params := online_offline.NewGetDatabaseFileParams()
+	params.Dbid = float64(dbid)
+	params.FileOperation = strconv.FormatInt(fnr, 10)
+	resp, _, err := clientInstance.OnlineOffline.GetDatabaseFile(params, auth)
+	if err != nil {
+		switch err.(type) {
+		case *online_offline.GetDatabaseFileBadRequest:
+			response := err.(*online_offline.GetDatabaseFileBadRequest)
+			fmt.Println(response.Payload.Error.Code, ":", response.Payload.Error.Message)
+		default:
+			fmt.Println("Error:", err)
+		}
+		return nil, err
+	}
+	if resp == nil {
+		return nil, fmt.Errorf("No FCB received for file %d", fnr)
+	}
+	return resp.Payload.File, nil
+}
+
+// extentBlocks number of allocated and free blocks of the DS, NI or UI extents
+func extentBlocks(extents []*models.FcbExtents) (allocated int64, free int64) {
+	for _, e := range extents {
+		if e.LastRabn >= e.FirstRabn && e.FirstRabn > 0 {
+			allocated += e.LastRabn - e.FirstRabn + 1
+			free += e.FreeOrIsn
+		}
+	}
+	return
+}
+
+// acBlocks number of allocated address converter blocks, the free value of AC extents is an ISN
+func acBlocks(extents []*models.FcbExtents) (allocated int64) {
+	for _, e := range extents {
+		if e.LastRabn >= e.FirstRabn && e.FirstRabn > 0 {
+			allocated += e.LastRabn - e.FirstRabn + 1
+		}
+	}
+	return
+}
+
+// upgrade convert the block counts of samples of earlier versions into bytes
+func (sample *capacitySample) upgrade() {
+	if sample.TotalBytes != nil {
+		return
+	}
+	sample.TotalBytes = make(map[string]int64)
+	sample.FreeBytes = make(map[string]int64)
+	for t, blocks := range sample.Blocks {
+		sample.TotalBytes[t] = blocks * sample.BlockSize[t]
+		sample.FreeBytes[t] = sample.FreeBlocks[t] * sample.BlockSize[t]
+	}
+}
+
+// containerBytes sum the size and the free space of the containers per type in bytes. The free space
+// table entries are assigned to the containers holding the RABN range, using their block size.
+func containerBytes(container *models.ContainerFstContainer) (total map[string]int64, free map[string]int64) {
+	total = make(map[string]int64)
+	free = make(map[string]int64)
+	for _, c := range container.ContainerList {
+		total[strings.ToUpper(c.Type)] += (c.LastExtentRabn - c.FirstExtentRabn + 1) * sizeInBytes(c.BlockSize, c.BlockUnit)
+	}
+	for _, f := range container.FreeSpaceTable {
+		t := strings.ToUpper(f.Type)
+		for _, c := range container.ContainerList {
+			if strings.ToUpper(c.Type) != t {
+				continue
+			}
+			first, last := f.FirstRABN, f.LastRABN
+			if c.FirstExtentRabn > first {
+				first = c.FirstExtentRabn
+			}
+			if c.LastExtentRabn < last {
+				last = c.LastExtentRabn
+			}
+			if last >= first {
+				free[t] += (last - first + 1) * sizeInBytes(c.BlockSize, c.BlockUnit)
+			}
+		}
+	}
+	return
+}
+
+// collectCapacity sample the container, free space table and file usage of the database
+func collectCapacity(clientInstance *client.AdabasAdmin, dbid int, auth runtime.ClientAuthInfoWriter) (*capacitySample, error) {
+	container, err := getContainer(clientInstance, dbid, auth)
+	if err != nil {
+		return nil, err
+	}
+	sample := &capacitySample{Time: time.Now()}
+	sample.TotalBytes, sample.FreeBytes = containerBytes(container)
+	files, err := getFiles(clientInstance, dbid, auth)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		fcb, ferr := getFcb(clientInstance, dbid, f.FileNr, auth)
+		if ferr != nil {
+			return nil, ferr
+		}
+		space := fileSpaceOf(fcb)
+		sample.Files = append(sample.Files, capacityFile{File: f.FileNr, Name: fcb.Name,
+			Asso: space.ac + space.niUsed + space.uiUsed, Data: space.dsUsed,
+			TopIsn: fcb.TopIsn, MaxIsn: fcb.MaxIsn})
+	}
+	return sample, nil
+}
+
+// Capacity sample the database capacity into the local history and forecast the exhaustion of the
+// ASSO and DATA container. The parameter horizon=<days> define the forecast period (default 90 days),
+// collect=false create the forecast without taking a new sample. With interval=<seconds> samples are
+// collected periodically, count=<n> stops after n samples.
+func Capacity(clientInstance *client.AdabasAdmin, dbid int, param string, auth runtime.ClientAuthInfoWriter) error {
+	if dbid < 1 {
+		fmt.Println("Please add option -dbid Adabas database id")
+		return fmt.Errorf("Please add option -dbid Adabas database id")
+	}
+	values, err := parseKeyValues(param, "horizon", "collect", "interval", "count")
+	if err != nil {
+		fmt.Println(err.Error())
+		return err
+	}
+	horizon := 90
+	if h, ok := values["horizon"]; ok {
+		horizon, err = strconv.Atoi(h)
+		if err != nil || horizon < 1 {
+			fmt.Println("Parameter horizon need number of days")
+			return fmt.Errorf("Parameter horizon need number of days")
+		}
+	}
+	collect := true
+	if c, ok := values["collect"]; ok {
+		collect, err = strconv.ParseBool(c)
+		if err != nil {
+			fmt.Println("Parameter collect need true or false")
+			return fmt.Errorf("Parameter collect need true or false")
+		}
+	}
+	interval := 0
+	if i, ok := values["interval"]; ok {
+		interval, err = strconv.Atoi(i)
+		if err != nil || interval < 1 {
+			fmt.Println("Parameter interval need number of seconds")
+			return fmt.Errorf("Parameter interval need number of seconds")
+		}
+		if !collect {
+			fmt.Println("Parameter interval not valid with collect=false")
+			return fmt.Errorf("Parameter interval not valid with collect=false")
+		}
+	}
+	count := 1
+	if interval > 0 {
+		count = 0
+	}
+	if c, ok := values["count"]; ok {
+		count, err = strconv.Atoi(c)
+		if err != nil || count < 1 || interval == 0 {
+			fmt.Println("Parameter count need number of samples and parameter interval")
+			return fmt.Errorf("Parameter count need number of samples and parameter interval")
+		}
+	}
+
+	server := historyServer(clientInstance)
+	return collectPeriodically(count, time.Duration(interval)*time.Second, time.Sleep, func() error {
+		if collect {
+			sample, cerr := collectCapacity(clientInstance, dbid, auth)
+			if cerr != nil {
+				return cerr
+			}
+			cerr = appendHistory(capacityHistory, server, dbid, sample)
+			if cerr != nil {
+				return cerr
+			}
+		}
+		samples, cerr := readCapacitySamples(server, dbid)
+		if cerr != nil {
+			return cerr
+		}
+		printForecast(dbid, samples, horizon)
+		return nil
+	})
+}
+
+// collectPeriodically call collect count times waiting the interval in between, a count of 0
+// collects until an error occurs
+func collectPeriodically(count int, interval time.Duration, sleep func(time.Duration), collect func() error) error {
+	for n := 1; ; n++ {
+		err := collect()
+		if err != nil {
+			return err
+		}
+		if count > 0 && n >= count {
+			return nil
+		}
+		sleep(interval)
+	}
+}
+
+// readCapacitySamples read the capacity samples of the local history
+func readCapacitySamples(server string, dbid int) ([]*capacitySample, error) {
+	entries, err := readHistory(capacityHistory, server, dbid)
+	if err != nil {
+		return nil, err
+	}
+	samples := make([]*capacitySample, 0)
+	for _, e := range entries {
+		sample := &capacitySample{}
+		if json.Unmarshal(e, sample) == nil {
+			sample.upgrade()
+			samples = append(samples, sample)
+		}
+	}
+	return samples, nil
+}
+
+// printForecast print the container and file growth forecast out of the samples
+func printForecast(dbid int, samples []*capacitySample, horizon int) {
+	p := message.NewPrinter(language.English)
+	p.Println()
+	if len(samples) < 2 {
+		p.Printf("Database %03d has %d capacity samples, need at least two samples for a forecast\n", dbid, len(samples))
+		return
+	}
+	first := samples[0].Time
+	last := samples[len(samples)-1]
+	p.Printf("Database %03d capacity forecast out of %d samples from %s to %s\n\n", dbid, len(samples),
+		first.Format("2006-01-02 15:04"), last.Time.Format("2006-01-02 15:04"))
+	p.Println(" Type        Used MB       Free MB  Growth MB/day  Exhaustion")
+	for _, t := range []string{"ASSO", "DATA"} {
+		points := make([]trendPoint, 0)
+		for _, s := range samples {
+			if total, ok := s.TotalBytes[t]; ok {
+				points = append(points, trendPoint{day: s.Time.Sub(first).Hours() / 24, value: float64(total - s.FreeBytes[t])})
+			}
+		}
+		used := last.TotalBytes[t] - last.FreeBytes[t]
+		slope, _, ok := linearTrend(points)
+		days, grows := daysUntil(slope, float64(used), float64(last.TotalBytes[t]))
+		exhaustion := "no growth"
+		if ok && grows {
+			exhaustion = fmt.Sprintf("%s (%d days)", last.Time.Add(time.Duration(days*24)*time.Hour).Format("2006-01-02"), int(days))
+		}
+		p.Printf(" %-4s %13d %13d %14.1f  %s\n", t, used>>20, last.FreeBytes[t]>>20, slope/(1<<20), exhaustion)
+		if ok && grows && days < float64(horizon) {
+			needed := int64(slope*float64(horizon)) - last.FreeBytes[t]
+			p.Printf("      recommendation: add %s container of at least %dM to cover %d days\n", t,
+				needed/(1<<20)+1, horizon)
+		}
+	}
+
+	p.Println()
+	p.Println(" File Name              ASSO/day    DATA/day  ISN exhaustion")
+	type fileTrend struct {
+		file       capacityFile
+		asso, data float64
+		isn        string
+	}
+	trends := make([]fileTrend, 0)
+	for _, f := range last.Files {
+		var assoPoints, dataPoints, isnPoints []trendPoint
+		for _, s := range samples {
+			for _, sf := range s.Files {
+				if sf.File == f.File {
+					day := s.Time.Sub(first).Hours() / 24
+					assoPoints = append(assoPoints, trendPoint{day: day, value: float64(sf.Asso)})
+					dataPoints = append(dataPoints, trendPoint{day: day, value: float64(sf.Data)})
+					isnPoints = append(isnPoints, trendPoint{day: day, value: float64(sf.TopIsn)})
+				}
+			}
+		}
+		ft := fileTrend{file: f, isn: "-"}
+		ft.asso, _, _ = linearTrend(assoPoints)
+		ft.data, _, _ = linearTrend(dataPoints)
+		isnSlope, _, ok := linearTrend(isnPoints)
+		if days, grows := daysUntil(isnSlope, float64(f.TopIsn), float64(f.MaxIsn)); ok && grows && f.MaxIsn > 0 {
+			ft.isn = fmt.Sprintf("%s (%d days)", last.Time.Add(time.Duration(days*24)*time.Hour).Format("2006-01-02"), int(days))
+		}
+		trends = append(trends, ft)
+	}
+	sort.SliceStable(trends, func(i, j int) bool {
+		return trends[i].asso+trends[i].data > trends[j].asso+trends[j].data
+	})
+	for _, ft := range trends {
+		p.Printf(" %4d %-16s %9.1f %11.1f  %s\n", ft.file.File, ft.file.Name, ft.asso, ft.data, ft.isn)
+	}
+}
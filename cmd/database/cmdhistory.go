@@ -0,0 +1,276 @@
+/*
+* Copyright © 2018 Software AG, Darmstadt, Germany and/or its licensors
+*
+* SPDX-License-Identifier: Apache-2.0
+*
+*   Licensed under the Apache License, Version 2.0 (the "License");
+*   you may not use this file except in compliance with the License.
+*   You may obtain a copy of the License at
+*
+*       http://www.apache.org/licenses/LICENSE-2.0
+*
+*   Unless required by applicable law or agreed to in writing, software
+*   distributed under the License is distributed on an "AS IS" BASIS,
+*   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*   See the License for the specific language governing permissions and
+*   limitations under the License.
+*
+ */
+
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-openapi/runtime"
+	"softwareag.com/client"
+)
+
+// commandHistory local history of the command statistics samples
+const commandHistory = "commandstats"
+
+// commandSample cumulated command counts of the database at a point in time
+type commandSample struct {
+	Time   time.Time        `json:"time"`
+	Counts map[string]int64 `json:"counts"`
+}
+
+// commandInterval commands executed between two samples
+type commandInterval struct {
+	start  time.Time
+	end    time.Time
+	counts map[string]int64
+}
+
+// commandSpike interval with an unusual rate of a command
+type commandSpike struct {
+	interval *commandInterval
+	command  string
+	rate     float64
+	median   float64
+}
+
+// commandIntervals calculate the commands executed between consecutive samples. Lower counts than in
+// the previous sample mean a nucleus restart, the counts are taken as they are.
+func commandIntervals(samples []*commandSample) []*commandInterval {
+	intervals := make([]*commandInterval, 0)
+	for i := 1; i < len(samples); i++ {
+		previous, current := samples[i-1], samples[i]
+		if !current.Time.After(previous.Time) {
+			continue
+		}
+		interval := &commandInterval{start: previous.Time, end: current.Time, counts: make(map[string]int64)}
+		restart := false
+		for c, n := range current.Counts {
+			if n < previous.Counts[c] {
+				restart = true
+			}
+		}
+		for c, n := range current.Counts {
+			if !restart {
+				n -= previous.Counts[c]
+			}
+			if n > 0 {
+				interval.counts[c] = n
+			}
+		}
+		intervals = append(intervals, interval)
+	}
+	return intervals
+}
+
+// commandMix sum of the commands and the seconds of the intervals ending in the time window
+func commandMix(intervals []*commandInterval, from, to time.Time) (map[string]int64, float64) {
+	totals := make(map[string]int64)
+	seconds := 0.0
+	for _, i := range intervals {
+		if i.end.Before(from) || i.end.After(to) {
+			continue
+		}
+		seconds += i.end.Sub(i.start).Seconds()
+		for c, n := range i.counts {
+			totals[c] += n
+		}
+	}
+	return totals, seconds
+}
+
+func (i *commandInterval) rate(command string) float64 {
+	return float64(i.counts[command]) / i.end.Sub(i.start).Seconds()
+}
+
+// detectSpikes find intervals where the rate of a command exceeds the median rate of the command by
+// the given factor. Intervals with less than minimum commands are ignored.
+func detectSpikes(intervals []*commandInterval, factor float64, minimum int64) []*commandSpike {
+	spikes := make([]*commandSpike, 0)
+	commands := make(map[string]bool)
+	for _, i := range intervals {
+		for c := range i.counts {
+			commands[c] = true
+		}
+	}
+	for c := range commands {
+		rates := make([]float64, 0, len(intervals))
+		for _, i := range intervals {
+			rates = append(rates, i.rate(c))
+		}
+		sort.Float64s(rates)
+		median := rates[len(rates)/2]
+		if len(rates)%2 == 0 {
+			median = (rates[len(rates)/2-1] + rates[len(rates)/2]) / 2
+		}
+		for _, i := range intervals {
+			if i.counts[c] >= minimum && i.rate(c) > median*factor {
+				spikes = append(spikes, &commandSpike{interval: i, command: c, rate: i.rate(c), median: median})
+			}
+		}
+	}
+	sort.Slice(spikes, func(i, j int) bool {
+		if !spikes[i].interval.end.Equal(spikes[j].interval.end) {
+			return spikes[i].interval.end.Before(spikes[j].interval.end)
+		}
+		return spikes[i].command < spikes[j].command
+	})
+	return spikes
+}
+
+// printCommandMix print the commands of the window sorted by count with percentage and rate
+func printCommandMix(title string, totals map[string]int64, seconds float64, compare map[string]int64, compareSeconds float64) {
+	fmt.Printf("\n %s:\n\n", title)
+	if seconds == 0 {
+		fmt.Println("  No samples in the time window")
+		return
+	}
+	counts := make(map[string]int)
+	total := int64(0)
+	for c, n := range totals {
+		counts[c] = int(n)
+		total += n
+	}
+	if compare != nil {
+		for c := range compare {
+			if _, ok := counts[c]; !ok {
+				counts[c] = 0
+			}
+		}
+		fmt.Printf("  %-4s %12s %7s %10s %10s %8s\n", "Cmd", "Count", "%", "Rate/s", "Before/s", "Change")
+	} else {
+		fmt.Printf("  %-4s %12s %7s %10s\n", "Cmd", "Count", "%", "Rate/s")
+	}
+	for _, e := range rank(counts) {
+		percent := 0.0
+		if total > 0 {
+			percent = float64(e.count) * 100 / float64(total)
+		}
+		rate := float64(e.count) / seconds
+		if compare == nil {
+			fmt.Printf("  %-4s %12d %6.1f%% %10.2f\n", e.name, e.count, percent, rate)
+			continue
+		}
+		before := 0.0
+		if compareSeconds > 0 {
+			before = float64(compare[e.name]) / compareSeconds
+		}
+		change := "new"
+		if before > 0 {
+			change = fmt.Sprintf("%+.0f%%", (rate-before)*100/before)
+		}
+		fmt.Printf("  %-4s %12d %6.1f%% %10.2f %10.2f %8s\n", e.name, e.count, percent, rate, before, change)
+	}
+	fmt.Printf("  %-4s %12d\n", "All", total)
+}
+
+// CommandHistory store a sample of the command statistics in the local history and report the command
+// mix of the last window hours, optional compared with the window compare hours ago, and spikes of commands
+// exceeding the median rate by the spike factor, like window=24,compare=168,spike=5.
+func CommandHistory(clientInstance *client.AdabasAdmin, dbid int, param string, auth runtime.ClientAuthInfoWriter) error {
+	if dbid < 1 {
+		fmt.Println("Please add option -dbid Adabas database id")
+		return fmt.Errorf("Please add option -dbid Adabas database id")
+	}
+	values, err := parseKeyValues(param, "collect", "window", "compare", "spike", "minimum")
+	if err != nil {
+		fmt.Println(err.Error())
+		return err
+	}
+	settings := map[string]float64{"window": 24, "compare": 0, "spike": 5, "minimum": 100}
+	for k, v := range values {
+		if k == "collect" {
+			continue
+		}
+		f, perr := strconv.ParseFloat(v, 64)
+		if perr != nil || f < 0 {
+			fmt.Printf("Parameter %s=%s not valid, need positive number\n", k, v)
+			return fmt.Errorf("Parameter %s=%s not valid", k, v)
+		}
+		settings[k] = f
+	}
+	collect := true
+	if c, ok := values["collect"]; ok {
+		collect, err = strconv.ParseBool(c)
+		if err != nil {
+			fmt.Println("Parameter collect need true or false")
+			return fmt.Errorf("Parameter collect need true or false")
+		}
+	}
+	if collect {
+		commands, cerr := getCommandStats(clientInstance, dbid, auth)
+		if cerr != nil {
+			return cerr
+		}
+		sample := &commandSample{Time: time.Now(), Counts: make(map[string]int64)}
+		for _, c := range commands {
+			sample.Counts[strings.TrimSpace(c.CommandName)] = c.CommandCount
+		}
+		err = appendHistory(commandHistory, historyServer(clientInstance), dbid, sample)
+		if err != nil {
+			return err
+		}
+	}
+
+	entries, err := readHistory(commandHistory, historyServer(clientInstance), dbid)
+	if err != nil {
+		return err
+	}
+	samples := make([]*commandSample, 0)
+	for _, e := range entries {
+		sample := &commandSample{}
+		if json.Unmarshal(e, sample) == nil {
+			samples = append(samples, sample)
+		}
+	}
+	fmt.Printf("\nCommand statistics history of database %03d, %d samples\n", dbid, len(samples))
+	intervals := commandIntervals(samples)
+	if len(intervals) == 0 {
+		fmt.Println("At least two samples needed, collect samples using -repeat")
+		return nil
+	}
+	window := time.Duration(settings["window"] * float64(time.Hour))
+	now := time.Now()
+	totals, seconds := commandMix(intervals, now.Add(-window), now)
+	if settings["compare"] > 0 {
+		back := time.Duration(settings["compare"] * float64(time.Hour))
+		before, beforeSeconds := commandMix(intervals, now.Add(-back-window), now.Add(-back))
+		printCommandMix(fmt.Sprintf("Command mix of the last %v compared with %v before", window, back),
+			totals, seconds, before, beforeSeconds)
+	} else {
+		printCommandMix(fmt.Sprintf("Command mix of the last %v", window), totals, seconds, nil, 0)
+	}
+
+	spikes := detectSpikes(intervals, settings["spike"], int64(settings["minimum"]))
+	fmt.Printf("\n Spikes above %.1f times the median rate:\n\n", settings["spike"])
+	if len(spikes) == 0 {
+		fmt.Println("  No spikes found")
+	}
+	for _, s := range spikes {
+		fmt.Printf("  %s - %s  %-4s %10.2f/s  median %.2f/s\n", s.interval.start.Format("2006-01-02 15:04:05"),
+			s.interval.end.Format("15:04:05"), s.command, s.rate, s.median)
+	}
+	fmt.Println()
+	return nil
+}
@@ -0,0 +1,297 @@
+/*
+* Copyright © 2018 Software AG, Darmstadt, Germany and/or its licensors
+*
+* SPDX-License-Identifier: Apache-2.0
+*
+*   Licensed under the Apache License, Version 2.0 (the "License");
+*   you may not use this file except in compliance with the License.
+*   You may obtain a copy of the License at
+*
+*       http://www.apache.org/licenses/LICENSE-2.0
+*
+*   Unless required by applicable law or agreed to in writing, software
+*   distributed under the License is distributed on an "AS IS" BASIS,
+*   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*   See the License for the specific language governing permissions and
+*   limitations under the License.
+*
+ */
+
+package database
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-openapi/runtime"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"softwareag.com/client"
+	"softwareag.com/client/offline"
+	"softwareag.com/client/online_offline"
+	"softwareag.com/models"
+)
+
+var sizeUnitFactor = map[string]int64{"B": 1, "K": 1 << 10, "M": 1 << 20, "G": 1 << 30, "T": 1 << 40}
+
+// containerBlockSizes valid Adabas container block sizes in bytes
+var containerBlockSizes = []int64{1 << 10, 2 << 10, 4 << 10, 8 << 10, 16 << 10, 32 << 10}
+
+var pathFormat = regexp.MustCompile(`%0?[0-9]*d`)
+
+// parseSize parse size values like 60M or 2G into the size and the unit, the unit is required
+func parseSize(value string) (int64, string, error) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	if value == "" {
+		return 0, "", fmt.Errorf("Size missing")
+	}
+	unit := value[len(value)-1:]
+	size, err := strconv.ParseInt(value[:len(value)-1], 10, 64)
+	if _, ok := sizeUnitFactor[unit]; !ok || unit == "B" || err != nil || size <= 0 {
+		return 0, "", fmt.Errorf("Size %s not valid, need number with unit K, M, G or T", value)
+	}
+	return size, unit, nil
+}
+
+// sizeInBytes size of the given unit in bytes
+func sizeInBytes(size int64, unit string) int64 {
+	if f, ok := sizeUnitFactor[strings.ToUpper(unit)]; ok {
+		return size * f
+	}
+	return size
+}
+
+// containerPath expand the path template, each %03d is replaced by the database id and %n by the
+// container number. Environment variables like ${ADADATADIR} are resolved by the server.
+func containerPath(template string, dbid int, number int64) string {
+	path := pathFormat.ReplaceAllStringFunc(template, func(f string) string {
+		return fmt.Sprintf(f, dbid)
+	})
+	return strings.Replace(path, "%n", strconv.FormatInt(number, 10), -1)
+}
+
+// getContainer read the container and free space table of the database
+func getContainer(clientInstance *client.AdabasAdmin, dbid int, auth runtime.ClientAuthInfoWriter) (*models.ContainerFstContainer, error) {
+	params := online_offline.NewGetDatabaseContainerParams()
+	params.Dbid = float64(dbid)
+	resp, err := clientInstance.OnlineOffline.GetDatabaseContainer(params, auth)
+	if err != nil {
+		switch err.(type) {
+		case *online_offline.GetDatabaseContainerBadRequest:
+			response := err.(*online_offline.GetDatabaseContainerBadRequest)
+			fmt.Println(response.Payload.Error.Code, ":", response.Payload.Error.Message)
+		default:
+			fmt.Println("Error:", err)
+		}
+		return nil, err
+	}
+	return resp.Payload.Container, nil
+}
+
+// containerUsage print the current container usage of the container type
+func containerUsage(p *message.Printer, container *models.ContainerFstContainer, containerType string) []*models.ContainerInfo {
+	list := make([]*models.ContainerInfo, 0)
+	total := int64(0)
+	for _, c := range container.ContainerList {
+		if strings.ToUpper(c.Type) == containerType {
+			list = append(list, c)
+			total += c.LastExtentRabn - c.FirstExtentRabn + 1
+		}
+	}
+	free := int64(0)
+	for _, f := range container.FreeSpaceTable {
+		if strings.ToUpper(f.Type) == containerType {
+			free += f.LastRABN - f.FirstRABN + 1
+		}
+	}
+	p.Printf("Current %s container:\n", containerType)
+	for _, c := range list {
+		p.Printf(" %5s%-2d %8d%s %8d%s  %s\n", c.Type, c.ContainerNumber, c.BlockSize, c.BlockUnit, c.Size, c.SizeUnit, c.Path)
+	}
+	if total > 0 {
+		p.Printf(" %d of %d blocks free (%.1f%% used)\n", free, total, float64(total-free)*100/float64(total))
+	}
+	p.Println()
+	return list
+}
+
+// checkContainer check the new container against the current containers of the database. The path
+// must not be used by another container, a block size with known unit must be a valid Adabas block
+// size and the container must hold at least one block.
+func checkContainer(info *models.ContainerInfo, container *models.ContainerFstContainer) []string {
+	problems := make([]string, 0)
+	for _, c := range container.ContainerList {
+		if strings.TrimSpace(c.Path) == strings.TrimSpace(info.Path) {
+			problems = append(problems, fmt.Sprintf("Container path %s already used by %s%d", c.Path, c.Type, c.ContainerNumber))
+		}
+		if strings.ToUpper(c.Type) == info.Type && c.ContainerNumber == info.ContainerNumber {
+			problems = append(problems, fmt.Sprintf("Container %s%d already exists", info.Type, info.ContainerNumber))
+		}
+	}
+	if _, ok := sizeUnitFactor[strings.ToUpper(info.BlockUnit)]; !ok {
+		return problems
+	}
+	blockSize := sizeInBytes(info.BlockSize, info.BlockUnit)
+	valid := false
+	for _, b := range containerBlockSizes {
+		if blockSize == b {
+			valid = true
+		}
+	}
+	if !valid {
+		problems = append(problems, fmt.Sprintf("Block size %d%s not valid, need 1K, 2K, 4K, 8K, 16K or 32K",
+			info.BlockSize, info.BlockUnit))
+	} else if sizeInBytes(info.Size, info.SizeUnit) < blockSize {
+		problems = append(problems, fmt.Sprintf("Container size %d%s smaller than block size %d%s", info.Size,
+			info.SizeUnit, info.BlockSize, info.BlockUnit))
+	}
+	return problems
+}
+
+// AddContainer add a container to the database. The parameter defines type, size, block size and path
+// of the container, like type=DATA,size=2G,blocksize=32K,path=${ADADATADIR}/db%03d/DATA%n.%03d
+func AddContainer(clientInstance *client.AdabasAdmin, dbid int, param string, auth runtime.ClientAuthInfoWriter) error {
+	if dbid < 1 {
+		fmt.Println("Please add option -dbid Adabas database id")
+		return fmt.Errorf("Please add option -dbid Adabas database id")
+	}
+	values, err := parseKeyValues(param, "type", "size", "blocksize", "path")
+	if err != nil {
+		fmt.Println(err.Error())
+		return err
+	}
+	containerType := strings.ToUpper(values["type"])
+	switch containerType {
+	case "ASSO", "DATA", "WORK":
+	default:
+		fmt.Println("Please add parameter type=ASSO, type=DATA or type=WORK")
+		return fmt.Errorf("Please add parameter type=ASSO, type=DATA or type=WORK")
+	}
+	if values["path"] == "" {
+		fmt.Println("Please add parameter path with the container path")
+		return fmt.Errorf("Please add parameter path with the container path")
+	}
+	info := &models.ContainerInfo{Type: containerType}
+	info.Size, info.SizeUnit, err = parseSize(values["size"])
+	if err != nil {
+		fmt.Println(err.Error())
+		return err
+	}
+
+	container, err := getContainer(clientInstance, dbid, auth)
+	if err != nil {
+		return err
+	}
+	p := message.NewPrinter(language.English)
+	p.Println()
+	current := containerUsage(p, container, containerType)
+	for _, c := range current {
+		if c.ContainerNumber >= info.ContainerNumber {
+			info.ContainerNumber = c.ContainerNumber + 1
+		}
+	}
+	if info.ContainerNumber == 0 {
+		info.ContainerNumber = 1
+	}
+	if values["blocksize"] != "" {
+		info.BlockSize, info.BlockUnit, err = parseSize(values["blocksize"])
+		if err != nil {
+			fmt.Println(err.Error())
+			return err
+		}
+	} else if len(current) > 0 {
+		info.BlockSize = current[len(current)-1].BlockSize
+		info.BlockUnit = current[len(current)-1].BlockUnit
+	} else {
+		fmt.Println("Please add parameter blocksize, no container of the type available")
+		return fmt.Errorf("Please add parameter blocksize")
+	}
+	info.Path = containerPath(values["path"], dbid, info.ContainerNumber)
+	problems := checkContainer(info, container)
+	if len(problems) > 0 {
+		fmt.Println("Container pre-check failed:")
+		for _, problem := range problems {
+			fmt.Println(" " + problem)
+		}
+		return fmt.Errorf("Container pre-check failed with %d problems", len(problems))
+	}
+	if len(current) > 0 && sizeInBytes(info.BlockSize, info.BlockUnit) !=
+		sizeInBytes(current[0].BlockSize, current[0].BlockUnit) {
+		p.Printf("Warning, block size %d%s differs from current block size %d%s\n", info.BlockSize, info.BlockUnit,
+			current[0].BlockSize, current[0].BlockUnit)
+	}
+	p.Printf("Add %s%d %d%s with block size %d%s at %s\n", info.Type, info.ContainerNumber, info.Size, info.SizeUnit,
+		info.BlockSize, info.BlockUnit, info.Path)
+
+	params := online_offline.NewAddAdabasContainerParams()
+	params.Dbid = float64(dbid)
+	params.Database = info
+	resp, err := clientInstance.OnlineOffline.AddAdabasContainer(params, auth)
+	if err != nil {
+		switch err.(type) {
+		case *online_offline.AddAdabasContainerBadRequest:
+			response := err.(*online_offline.AddAdabasContainerBadRequest)
+			fmt.Println(response.Payload.Error.Code, ":", response.Payload.Error.Message)
+		default:
+			fmt.Println("Error:", err)
+		}
+		return err
+	}
+	fmt.Println("Status: ", resp.Payload.Status.Message)
+	return nil
+}
+
+// RemoveContainer remove the last ASSO or DATA container of the database, the container must be unused
+func RemoveContainer(clientInstance *client.AdabasAdmin, dbid int, param string, auth runtime.ClientAuthInfoWriter) error {
+	if dbid < 1 {
+		fmt.Println("Please add option -dbid Adabas database id")
+		return fmt.Errorf("Please add option -dbid Adabas database id")
+	}
+	containerType := strings.ToUpper(strings.TrimSpace(param))
+	if containerType != "ASSO" && containerType != "DATA" {
+		fmt.Println("Please add parameter ASSO or DATA")
+		return fmt.Errorf("Please add parameter ASSO or DATA")
+	}
+	container, err := getContainer(clientInstance, dbid, auth)
+	if err != nil {
+		return err
+	}
+	p := message.NewPrinter(language.English)
+	p.Println()
+	current := containerUsage(p, container, containerType)
+	if len(current) < 2 {
+		fmt.Printf("Only one %s container available, cannot be removed\n", containerType)
+		return fmt.Errorf("Only one %s container available", containerType)
+	}
+	last := current[len(current)-1]
+	for _, c := range current {
+		if c.LastExtentRabn > last.LastExtentRabn {
+			last = c
+		}
+	}
+	if last.FirstUnusedRabn > last.FirstExtentRabn {
+		p.Printf("Container %s%d is used up to RABN %d, cannot be removed\n", last.Type, last.ContainerNumber,
+			last.FirstUnusedRabn-1)
+		return fmt.Errorf("Container %s%d is in use", last.Type, last.ContainerNumber)
+	}
+	p.Printf("Remove %s%d %s\n", last.Type, last.ContainerNumber, last.Path)
+
+	params := offline.NewRemoveDatabaseContainerParams()
+	params.Dbid = float64(dbid)
+	params.ContainerType = containerType
+	resp, err := clientInstance.Offline.RemoveDatabaseContainer(params, auth)
+	if err != nil {
+		switch err.(type) {
+		case *offline.RemoveDatabaseContainerBadRequest:
+			response := err.(*offline.RemoveDatabaseContainerBadRequest)
+			fmt.Println(response.Payload.Error.Code, ":", response.Payload.Error.Message)
+		default:
+			fmt.Println("Error:", err)
+		}
+		return err
+	}
+	fmt.Println("Status: ", resp.Payload.Status.Message)
+	return nil
+}
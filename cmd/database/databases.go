@@ -26,7 +26,6 @@ import (
 	"io/ioutil"
 	"log"
 	"os"
-	"reflect"
 	"regexp"
 	"strconv"
 	"strings"
@@ -104,6 +103,58 @@ func Operation(clientInstance *client.AdabasAdmin, dbid int, operation string, a
 	return nil
 }
 
+// LogSwitch switch the protection log, the command log or the extended log of the database using
+// feofplog, feofclog or feofelog. For feofplog the parameter etsync=true waits for an ET synchronization.
+func LogSwitch(clientInstance *client.AdabasAdmin, dbid int, operation string, param string, auth runtime.ClientAuthInfoWriter) error {
+	if dbid < 1 {
+		fmt.Println("Please add option -dbid Adabas database id")
+		return fmt.Errorf("Please add option -dbid Adabas database id")
+	}
+	options, err := parseKeyValues(param, "etsync")
+	if err != nil {
+		fmt.Println(err.Error())
+		return err
+	}
+	params := online_offline.NewDatabaseOperationsParams()
+	params.DbidOperation = strconv.Itoa(dbid) + ":" + operation
+	if e, ok := options["etsync"]; ok {
+		if operation != "feofplog" {
+			fmt.Println("Parameter etsync only valid for feofplog")
+			return fmt.Errorf("Parameter etsync only valid for feofplog")
+		}
+		etsync, perr := strconv.ParseBool(e)
+		if perr != nil {
+			fmt.Println("Parameter etsync need true or false")
+			return fmt.Errorf("Parameter etsync need true or false")
+		}
+		params.Etsync = &etsync
+	}
+	fmt.Printf("\nSend following operation to database %v: %s\n", dbid, operation)
+	resp, accepted, err := clientInstance.OnlineOffline.DatabaseOperations(params, auth)
+	if err != nil {
+		switch err.(type) {
+		case *online_offline.DatabaseOperationsBadRequest:
+			response := err.(*online_offline.DatabaseOperationsBadRequest)
+			fmt.Println(response.Payload.Error.Code, ":", response.Payload.Error.Message)
+		default:
+			fmt.Println("Error:", err)
+		}
+		return err
+	}
+	if accepted != nil {
+		fmt.Printf("Operation accepted, running asynchronously: dbid=%d %s\n", accepted.Payload.Status.Dbid,
+			accepted.Payload.Status.Message)
+	}
+	if resp != nil {
+		if resp.Payload.Database != nil {
+			fmt.Printf("Operation done, database status dbid=%d %s\n", resp.Payload.Database.Dbid, resp.Payload.Database.Status)
+		} else {
+			fmt.Printf("Operation done successfully\n")
+		}
+	}
+	return nil
+}
+
 func createDatabaseInstance(dbid int, input string) *models.Database {
 	database := &models.Database{}
 	if input == "" {
@@ -338,19 +389,14 @@ func Information(clientInstance *client.AdabasAdmin, dbid int, auth runtime.Clie
 	return nil
 }
 
-// Activity database activity
-func Activity(clientInstance *client.AdabasAdmin, dbid int, auth runtime.ClientAuthInfoWriter) error {
-	params := online.NewGetDatabaseActStatsParams()
-	params.Dbid = float64(dbid)
-	resp, err := clientInstance.Online.GetDatabaseActStats(params, auth)
+// Activity database activity. The NUCTMP and NUCSRT I/O is not provided by the statistics. With a parameter
+// like interval=60,count=10 the throwbacks and work pool space waits are attributed per interval.
+func Activity(clientInstance *client.AdabasAdmin, dbid int, param string, auth runtime.ClientAuthInfoWriter) error {
+	if param != "" {
+		return activityIntervals(clientInstance, dbid, param, auth)
+	}
+	stats, err := getActivityStats(clientInstance, dbid, auth)
 	if err != nil {
-		switch err.(type) {
-		case *online.GetDatabaseActStatsBadRequest:
-			response := err.(*online.GetDatabaseActStatsBadRequest)
-			fmt.Println(response.Payload.Error.Code, ":", response.Payload.Error.Message)
-		default:
-			fmt.Println("Error:", err)
-		}
 		return err
 	}
 
@@ -361,22 +407,22 @@ func Activity(clientInstance *client.AdabasAdmin, dbid int, auth runtime.ClientA
 	p.Println()
 	p.Println(" I/O Activity                     Total   Throwbacks                       Total")
 	p.Println(" ------------                     -----   ----------                       -----")
-	p.Printf(" Buffer Pool               %12d   Waiting for UQ context    %12d\n", resp.Payload.Statistics.BufferPoolIO, resp.Payload.Statistics.ThbWaitUQContext)
-	p.Printf(" WORK Read                 %12d   Waiting for ISN           %12d\n", resp.Payload.Statistics.WorkReads, resp.Payload.Statistics.ThbWaitIsn)
-	p.Printf(" WORK Write                %12d   ET Sync                   %12d\n", resp.Payload.Statistics.WorkWrites, resp.Payload.Statistics.ThbEtSync)
-	p.Printf(" PLOG Write                %12d   DWP Overflow              %12d\n", resp.Payload.Statistics.PlogWrites, resp.Payload.Statistics.ThbDWPOverflow)
-	p.Printf(" NUCTMP                    %12d\n", -1)
-	p.Printf(" NUCSRT                    %12d\n", -1)
+	p.Printf(" Buffer Pool               %12d   Waiting for UQ context    %12d\n", stats.BufferPoolIO, stats.ThbWaitUQContext)
+	p.Printf(" WORK Read                 %12d   Waiting for ISN           %12d\n", stats.WorkReads, stats.ThbWaitIsn)
+	p.Printf(" WORK Write                %12d   ET Sync                   %12d\n", stats.WorkWrites, stats.ThbEtSync)
+	p.Printf(" PLOG Write                %12d   DWP Overflow              %12d\n", stats.PlogWrites, stats.ThbDWPOverflow)
+	p.Printf(" NUCTMP                    %12s\n", "n/a")
+	p.Printf(" NUCSRT                    %12s\n", "n/a")
 	p.Println()
 	p.Println(" Pool Hit Rate                    Total   Interrupts       Current         Total")
 	p.Println(" -------------                    -----   ----------       -------         -----")
-	p.Printf(" Buffer Pool                        %.1f%% WP Space Wait %10d    %10d\n", float64(resp.Payload.Statistics.BPHitRate), resp.Payload.Statistics.WPSpaceWaitCurrent, resp.Payload.Statistics.WpSpaceWaitTotal)
-	p.Printf(" Format pool                        %.1f%%\n", float64(resp.Payload.Statistics.FPHitRate))
+	p.Printf(" Buffer Pool                      %5.1f%%  WP Space Wait %10d    %10d\n", stats.BPHitRate, stats.WPSpaceWaitCurrent, stats.WpSpaceWaitTotal)
+	p.Printf(" Format pool                      %5.1f%%\n", float64(stats.FPHitRate))
 	return nil
 }
 
-// ThreadTable display thread table
-func ThreadTable(clientInstance *client.AdabasAdmin, dbid int, auth runtime.ClientAuthInfoWriter) error {
+// getThreadTable read the thread table of the database
+func getThreadTable(clientInstance *client.AdabasAdmin, dbid int, auth runtime.ClientAuthInfoWriter) ([]*models.Thread, error) {
 	params := online.NewGetDatabaseThreadTableParams()
 	params.Dbid = float64(dbid)
 	resp, err := clientInstance.Online.GetDatabaseThreadTable(params, auth)
@@ -389,6 +435,15 @@ func ThreadTable(clientInstance *client.AdabasAdmin, dbid int, auth runtime.Clie
 		default:
 			fmt.Println("Error:", err)
 		}
+		return nil, err
+	}
+	return resp.Payload.Threads, nil
+}
+
+// ThreadTable display thread table
+func ThreadTable(clientInstance *client.AdabasAdmin, dbid int, auth runtime.ClientAuthInfoWriter) error {
+	threads, err := getThreadTable(clientInstance, dbid, auth)
+	if err != nil {
 		return err
 	}
 
@@ -399,7 +454,7 @@ func ThreadTable(clientInstance *client.AdabasAdmin, dbid int, auth runtime.Clie
 	p.Println()
 	p.Println(" No     Cmd Count  File  Cmd  Status")
 	p.Println(" --     ---------  ----  ---  ------")
-	for _, t := range resp.Payload.Threads {
+	for _, t := range threads {
 		p.Printf(" %2d    %10d %5d   %2s  %s\n", t.Thread, t.CommandCount, t.File, t.CommandCode, t.Status)
 	}
 	return nil
@@ -436,22 +491,8 @@ func Parameter(clientInstance *client.AdabasAdmin, dbid int, para string, auth r
 
 	p.Println()
 	p.Printf(" Adabas %s parameter info:\n", para)
-	dbParameter := resp.Payload.Parameter
-	val := reflect.ValueOf(*dbParameter)
-	typ := reflect.TypeOf(*dbParameter)
-	for i := 0; i < typ.NumField(); i++ {
-		field := typ.Field(i)
-		n, ok := field.Tag.Lookup("json")
-		if !ok {
-			n = field.Name
-		} else {
-			ec := strings.IndexByte(n, ',')
-			if ec > 0 {
-				n = n[:ec]
-			}
-		}
-		v := val.Field(i)
-		fmt.Println("    ", n, "=", v)
+	for _, ps := range parameterList(resp.Payload.Parameter) {
+		fmt.Println("    ", ps.name, "=", ps.value)
 	}
 	return nil
 }
@@ -526,112 +567,6 @@ type parameterSet struct {
 	value string
 }
 
-// SetParameter set parameter
-func SetParameter(clientInstance *client.AdabasAdmin, dbid int, param string, auth runtime.ClientAuthInfoWriter) error {
-	params := online_offline.NewPutAdabasParameterParams()
-	params.Dbid = float64(dbid)
-	pmap := make(map[string]string)
-	params.Type = "static"
-	options := ""
-	for _, p := range strings.Split(param, ",") {
-		fmt.Println("Got", p)
-		if options != "" {
-			if strings.Contains(p, ")") {
-				options = options + "," + strings.Replace(p, ")", "", 1)
-				sendOption := options
-				params.OPTIONS = &sendOption
-				fmt.Println("End options=" + options)
-				options = ""
-				fmt.Printf("Options=%s", *params.OPTIONS)
-
-			} else {
-				options = options + "," + p
-				fmt.Println("Add options=" + options)
-			}
-			continue
-		}
-		v := strings.Split(p, "=")
-		fmt.Println(v)
-		if len(v) != 2 {
-			fmt.Printf("Parameter %s not valid, need of the type: param1=1,param2=ON,param3=(A,B)", param)
-			return fmt.Errorf("Parameter %s not valid, need of the type: param1=1,param2=ON,param3=(A,B)", param)
-		}
-		if strings.ToLower(v[0]) == "type" {
-			if strings.ToLower(v[1]) == "dynamic" {
-				params.Type = "dynamic"
-			}
-		} else {
-			if v[0] == "OPTIONS" {
-				options = strings.Replace(v[1], "(", "", 1)
-				if strings.Contains(v[1], ")") {
-					options = strings.Replace(options, ")", "", 1)
-					sendOption := options
-					if sendOption == "" {
-						sendOption = " "
-					}
-					params.OPTIONS = &sendOption
-					fmt.Println("End options=" + *params.OPTIONS)
-					options = ""
-				} else {
-					fmt.Println("Found options=" + options)
-				}
-			} else {
-				n := strings.Replace(v[0], "_", "", -1)
-				pmap[n] = v[1]
-				vp := reflect.ValueOf(params).Elem()
-				f := vp.FieldByName(n)
-				if f.IsValid() {
-					if f.IsNil() && f.CanSet() {
-						switch n {
-						case "NT", "TT", "NU", "NCL", "NISNHQ", "TNAE", "TNAA", "TNAX", "LAB", "LABX", "LBP",
-							"LWP", "LPXA", "ADATCPPORT", "ADATCPRECEIVER", "ADATCPATB", "ADATCPCONNECTIONS", "SSLVERIFY",
-							"APUUNITS", "APURECVS", "APUWORKERS", "SSLPORT",
-							"RPLBLOCKS", "RPLTOTAL", "RPLRECORDS", "WRITELIMIT":
-							i, err := strconv.Atoi(v[1])
-							if err != nil {
-								fmt.Println("Incorrect value for " + v[0])
-								return fmt.Errorf("Incorrect value for " + v[0])
-							}
-							i64 := int64(i)
-							f.Set(reflect.ValueOf(&i64))
-						case "ADATCP", "PLOG", "BI":
-							var i bool
-							switch strings.ToLower(v[1]) {
-							case "on", "yes", "true":
-								i = true
-							default:
-								i = false
-							}
-							f.Set(reflect.ValueOf(&i))
-
-						default:
-							f.Set(reflect.ValueOf(&v[1]))
-						}
-
-					}
-				}
-				fmt.Println("Set", f, f.Type())
-			}
-		}
-	}
-
-	resp, err := clientInstance.OnlineOffline.PutAdabasParameter(params, auth)
-	if err != nil {
-		switch err.(type) {
-		case *online_offline.PutAdabasParameterBadRequest:
-			response := err.(*online_offline.PutAdabasParameterBadRequest)
-			fmt.Println(response.Payload.Error.Code, ":", response.Payload.Error.Message)
-		default:
-			fmt.Println("Error:", err)
-		}
-		return err
-	}
-	fmt.Println()
-	fmt.Printf(" Adabas parameter: %s", resp.Payload.Status.Message)
-	fmt.Println()
-	return nil
-}
-
 func checkArConflict(value string) string {
 	if value == "1" {
 		return "CONTINUE"
@@ -889,59 +824,35 @@ func DeleteCheckpoints(clientInstance *client.AdabasAdmin, dbid int, timeRange s
 	return nil
 }
 
-// Ucb list UCBs
-func Ucb(clientInstance *client.AdabasAdmin, dbid int, auth runtime.ClientAuthInfoWriter) error {
-	params := online_offline.NewGetUCBParams()
-	params.Dbid = float64(dbid)
-
-	resp, err := clientInstance.OnlineOffline.GetUCB(params, auth)
+// Ucb list UCBs, optional filtered by utility, mode, file and age in days like utility=ADAORD,age=7
+func Ucb(clientInstance *client.AdabasAdmin, dbid int, param string, auth runtime.ClientAuthInfoWriter) error {
+	filter, _, err := parseUcbFilter(param)
 	if err != nil {
-		switch err.(type) {
-		case *online_offline.GetUCBBadRequest:
-			response := err.(*online_offline.GetUCBBadRequest)
-			fmt.Println(response.Payload.Error.Code, ":", response.Payload.Error.Message)
-		default:
-			fmt.Println("Error:", err)
-		}
+		fmt.Println(err.Error())
 		return err
 	}
-	fmt.Println()
-	fmt.Println(" UCB entries:")
-	fmt.Println()
-	fmt.Printf(" %-20s %-10s %-8s %-8s %-8s\n", "Date/Time", "Entry ID", "Utility", "Mode", "Files")
-	for _, c := range resp.Payload.UCB.UCB {
-		s, _ := json.Marshal(c.UcbFiles)
-		fmt.Printf(" %-20s %-10d %-8s %-8s %s\n", c.Date, c.Sequence, c.ID, c.DBMode, s)
+	entries, err := filterUcb(clientInstance, dbid, filter, auth)
+	if err != nil {
+		return err
 	}
+	printUcb(entries)
 	return nil
 }
 
 // DeleteUcb delete UCB entry
 func DeleteUcb(clientInstance *client.AdabasAdmin, dbid int, param string, auth runtime.ClientAuthInfoWriter) error {
-	params := online_offline.NewDeleteUCBParams()
-	params.Dbid = float64(dbid)
 	ucbid, err := strconv.Atoi(param)
 	if err != nil {
 		fmt.Println("Error UCB id parameter not numeric: ", err)
 		return err
 	}
-	params.Ucbid = int64(ucbid)
-
-	resp, respErr := clientInstance.OnlineOffline.DeleteUCB(params, auth)
-	err = respErr
+	status, err := deleteUcb(clientInstance, dbid, int64(ucbid), auth)
 	if err != nil {
-		switch err.(type) {
-		case *online_offline.DeleteUCBBadRequest:
-			response := err.(*online_offline.DeleteUCBBadRequest)
-			fmt.Println(response.Payload.Error.Code, ":", response.Payload.Error.Message)
-		default:
-			fmt.Println("Error:", err)
-		}
 		return err
 	}
 
 	fmt.Println()
-	fmt.Printf(" Adabas status of UCB delete: %s", resp.Payload.Status.Message)
+	fmt.Printf(" Adabas status of UCB delete: %s", status)
 	fmt.Println()
 	return nil
 }
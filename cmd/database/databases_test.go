@@ -20,10 +20,20 @@
 package database
 
 import (
+	"encoding/json"
+	"fmt"
+	"os"
 	"strconv"
 	"testing"
+	"time"
 
+	httptransport "github.com/go-openapi/runtime/client"
+	"github.com/go-openapi/strfmt"
 	"github.com/stretchr/testify/assert"
+	"softwareag.com/client"
+	"softwareag.com/client/online"
+	"softwareag.com/client/online_offline"
+	"softwareag.com/models"
 )
 
 func TestCheckLogging(t *testing.T) {
@@ -51,3 +61,531 @@ func TestInputList(t *testing.T) {
 	il.Set("BB")
 	assert.Equal(t, "AA,BB", il.String())
 }
+
+func TestNormalizeParameter(t *testing.T) {
+	assert.Equal(t, "IO,RB,SB,VB,OFF", normalizeParameter("LOGGING", "248"))
+	assert.Equal(t, "1,2", normalizeParameter("USEREXITS", "3"))
+	assert.Equal(t, "TRUNCATION,AUTO_EXPAND", normalizeParameter("OPTIONS", "257"))
+	assert.Equal(t, "AUTO_EXPAND", normalizeParameter("OPTIONS", "AUTO_EXPAND"))
+	assert.Equal(t, "1000", normalizeParameter("NU", "1000"))
+}
+
+func TestParseSize(t *testing.T) {
+	size, unit, err := parseSize("60M")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(60), size)
+	assert.Equal(t, "M", unit)
+	size, unit, err = parseSize("2g")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), size)
+	assert.Equal(t, "G", unit)
+	assert.Equal(t, int64(2<<30), sizeInBytes(size, unit))
+	_, _, err = parseSize("xM")
+	assert.Error(t, err)
+	_, _, err = parseSize("2")
+	assert.EqualError(t, err, "Size 2 not valid, need number with unit K, M, G or T")
+	_, _, err = parseSize("512B")
+	assert.Error(t, err)
+}
+
+func TestHistoryFile(t *testing.T) {
+	os.Setenv(historyEnvironment, "/tmp/history")
+	defer os.Unsetenv(historyEnvironment)
+	fileName, err := historyFile(capacityHistory, "adahost:8123", 24)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "/tmp/history/capacity-adahost_8123-024.json", fileName)
+	}
+	other, err := historyFile(capacityHistory, "otherhost:8123", 24)
+	if assert.NoError(t, err) {
+		assert.NotEqual(t, fileName, other)
+	}
+	assert.Equal(t, "adahost:8123", historyServer(client.New(httptransport.New("adahost:8123", "/", nil), strfmt.Default)))
+}
+
+func TestContainerBytes(t *testing.T) {
+	container := &models.ContainerFstContainer{ContainerList: []*models.ContainerInfo{
+		{Type: "ASSO", BlockSize: 8, BlockUnit: "K", FirstExtentRabn: 1, LastExtentRabn: 100},
+		{Type: "ASSO", BlockSize: 32, BlockUnit: "K", FirstExtentRabn: 101, LastExtentRabn: 150},
+		{Type: "DATA", BlockSize: 32, BlockUnit: "K", FirstExtentRabn: 1, LastExtentRabn: 10}},
+		FreeSpaceTable: []*models.FreeSpaceTable{{Type: "ASSO", FirstRABN: 91, LastRABN: 110},
+			{Type: "DATA", FirstRABN: 5, LastRABN: 10}}}
+	total, free := containerBytes(container)
+	assert.Equal(t, int64(100*8<<10+50*32<<10), total["ASSO"])
+	assert.Equal(t, int64(10*8<<10+10*32<<10), free["ASSO"])
+	assert.Equal(t, int64(10*32<<10), total["DATA"])
+	assert.Equal(t, int64(6*32<<10), free["DATA"])
+
+	sample := &capacitySample{}
+	assert.NoError(t, json.Unmarshal([]byte(`{"total":{"ASSO":100},"free":{"ASSO":10},"blockSize":{"ASSO":4096}}`), sample))
+	sample.upgrade()
+	assert.Equal(t, int64(100*4096), sample.TotalBytes["ASSO"])
+	assert.Equal(t, int64(10*4096), sample.FreeBytes["ASSO"])
+}
+
+func TestCollectPeriodically(t *testing.T) {
+	collected, slept := 0, 0
+	err := collectPeriodically(3, time.Minute, func(d time.Duration) {
+		assert.Equal(t, time.Minute, d)
+		slept++
+	}, func() error {
+		collected++
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, collected)
+	assert.Equal(t, 2, slept)
+
+	collected = 0
+	err = collectPeriodically(0, time.Minute, func(time.Duration) {}, func() error {
+		collected++
+		if collected == 5 {
+			return fmt.Errorf("stop")
+		}
+		return nil
+	})
+	assert.EqualError(t, err, "stop")
+	assert.Equal(t, 5, collected)
+}
+
+func TestReferentialJob(t *testing.T) {
+	assert.Nil(t, referentialJob(24, 11, nil))
+	job := referentialJob(24, 12, []*models.Field{{Name: "R1", Type: "PRIMARY", Length: 11, Format: "AA"},
+		{Name: "R2", Type: "FOREIGN", Length: 11, Format: "AA", Flags: "DC,UX",
+			SubFields: []*models.SubField{{SubName: "BA"}}}})
+	if assert.NotNil(t, job) {
+		assert.Equal(t, "refint-024-012", job.Job.Name)
+		assert.Equal(t, "ADAINV", job.Job.Utility)
+		assert.Len(t, job.Job.Parameters, 3)
+		assert.Equal(t, "refint=R2,11,AA,BA,DC,UX", job.Job.Parameters[2].Parameter)
+	}
+}
+
+func TestContainerPath(t *testing.T) {
+	assert.Equal(t, "${ADADATADIR}/db024/DATA2.024", containerPath("${ADADATADIR}/db%03d/DATA2.%03d", 24, 2))
+	assert.Equal(t, "/data/db024/DATA3.024", containerPath("/data/db%03d/DATA%n.%03d", 24, 3))
+
+	container := &models.ContainerFstContainer{ContainerList: []*models.ContainerInfo{
+		{Type: "DATA", ContainerNumber: 1, BlockSize: 32, BlockUnit: "K", Path: "/data/db024/DATA1.024"}}}
+	info := &models.ContainerInfo{Type: "DATA", ContainerNumber: 2, Size: 2, SizeUnit: "G", BlockSize: 32, BlockUnit: "K",
+		Path: "/data/db024/DATA2.024"}
+	assert.Empty(t, checkContainer(info, container))
+	info.Path = "/data/db024/DATA1.024"
+	info.BlockSize = 3
+	info.ContainerNumber = 1
+	assert.Equal(t, []string{"Container path /data/db024/DATA1.024 already used by DATA1", "Container DATA1 already exists",
+		"Block size 3K not valid, need 1K, 2K, 4K, 8K, 16K or 32K"}, checkContainer(info, container))
+	info = &models.ContainerInfo{Type: "DATA", ContainerNumber: 2, Size: 4, SizeUnit: "K", BlockSize: 8, BlockUnit: "K"}
+	assert.Equal(t, []string{"Container size 4K smaller than block size 8K"}, checkContainer(info, container))
+}
+
+func TestParseKeyValues(t *testing.T) {
+	values, err := parseKeyValues("type=DATA, size=2G", "type", "size", "path")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"type": "DATA", "size": "2G"}, values)
+	_, err = parseKeyValues("type=DATA,xx=1", "type")
+	assert.Error(t, err)
+}
+
+func TestLinearTrend(t *testing.T) {
+	slope, intercept, ok := linearTrend([]trendPoint{{0, 100}, {1, 110}, {2, 120}})
+	assert.True(t, ok)
+	assert.InDelta(t, 10.0, slope, 0.0001)
+	assert.InDelta(t, 100.0, intercept, 0.0001)
+	_, _, ok = linearTrend([]trendPoint{{1, 100}})
+	assert.False(t, ok)
+	days, grows := daysUntil(slope, 120, 220)
+	assert.True(t, grows)
+	assert.InDelta(t, 10.0, days, 0.0001)
+	_, grows = daysUntil(0, 120, 220)
+	assert.False(t, grows)
+}
+
+func TestParameterApply(t *testing.T) {
+	params := online_offline.NewPutAdabasParameterParams()
+	params.Type = "dynamic"
+	maxAvailable := true
+	info := &models.ParameterInfo{IsDynamic: true, IsMinValueAvailable: true, MinValue: 10,
+		IsMaxValueAvailable: &maxAvailable, MaxValue: 100}
+
+	nt := findParameter("nt")
+	v, err := nt.apply(params, "20", info)
+	assert.NoError(t, err)
+	assert.Equal(t, "20", v)
+	assert.Equal(t, int64(20), *params.NT)
+	_, err = nt.apply(params, "200", info)
+	assert.EqualError(t, err, "Value 200 of parameter NT above maximum 100")
+	_, err = nt.apply(params, "xx", info)
+	assert.Error(t, err)
+	info.IsDynamic = false
+	_, err = nt.apply(params, "20", info)
+	assert.EqualError(t, err, "Parameter NT cannot be changed dynamically")
+
+	v, err = findParameter("OPTIONS").apply(params, "(auto_expand,TRUNCATION)", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "AUTO_EXPAND,TRUNCATION", *params.OPTIONS)
+	_, err = findParameter("LOGGING").apply(params, "(CB,XX)", nil)
+	assert.Error(t, err)
+	_, err = findParameter("USEREXITS").apply(params, "3", nil)
+	assert.EqualError(t, err, "Value 3 of parameter USEREXITS invalid, valid values are: 1,2,4,11,14")
+	v, err = findParameter("USEREXITS").apply(params, "(11)", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "11", v)
+	v, err = findParameter("USEREXITS").apply(params, "4", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "4", *params.USEREXITS)
+	v, err = findParameter("rpl_records").apply(params, "5", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), *params.RPLRECORDS)
+	_, err = findParameter("BI").apply(params, "maybe", nil)
+	assert.Error(t, err)
+	assert.Nil(t, findParameter("XYZ"))
+}
+
+func TestParameterBody(t *testing.T) {
+	body, names, err := parameterBody([]parameterSet{{name: "nt", value: "20"}, {name: "BI", value: "yes"},
+		{name: "OPTIONS", value: "AUTO_EXPAND"}}, "static", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"NT", "BI", "OPTIONS"}, names)
+	assert.Equal(t, map[string]interface{}{"NT": int64(20), "BI": "ON", "OPTIONS": "AUTO_EXPAND"}, body)
+	_, _, err = parameterBody([]parameterSet{{name: "XYZ", value: "1"}, {name: "NT", value: "x"}}, "static", nil)
+	assert.EqualError(t, err, "2 invalid parameter values")
+	assert.Equal(t, "A,B", parameterFileValue([]interface{}{"A", "B"}))
+	assert.Equal(t, "1000", parameterFileValue(float64(1000)))
+	assert.True(t, sameParameterValue(findParameter("OPTIONS"), "257", "AUTO_EXPAND,TRUNCATION"))
+	assert.False(t, sameParameterValue(findParameter("NT"), "20", "21"))
+	assert.True(t, sameParameterValue(findParameter("USEREXITS"), "8", "(4)"))
+	assert.False(t, sameParameterValue(findParameter("USEREXITS"), "8", "1,2"))
+
+	before := &models.ParameterParameter{NT: 20, USEREXITS: "8", LOGGING: "OFF"}
+	values := serverParameterSets(before, []string{"USEREXITS", "NT"})
+	assert.Equal(t, []parameterSet{{name: "NT", value: "20"}, {name: "USEREXITS", value: "4"}}, values)
+	body, _, err = parameterBody(values, "static", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "4", body["USEREXITS"])
+}
+
+func TestLogSwitchEtsync(t *testing.T) {
+	err := LogSwitch(nil, 1, "feofplog", "etsync=foo", nil)
+	assert.EqualError(t, err, "Parameter etsync need true or false")
+	err = LogSwitch(nil, 1, "feofclog", "etsync=true", nil)
+	assert.EqualError(t, err, "Parameter etsync only valid for feofplog")
+}
+
+func TestSetParameterType(t *testing.T) {
+	err := SetParameter(nil, 1, "NT=20,type=xyz", nil)
+	assert.EqualError(t, err, "Please add parameter type=static or type=dynamic")
+}
+
+func TestParameterBodyFlags(t *testing.T) {
+	body, _, err := parameterBody([]parameterSet{{name: "BI", value: "1"}, {name: "PLOG", value: "0"}}, "static", nil)
+	if assert.NoError(t, err) {
+		assert.Equal(t, map[string]interface{}{"BI": "ON", "PLOG": "OFF"}, body)
+	}
+	_, _, err = parameterBody([]parameterSet{{name: "BI", value: "2"}}, "static", nil)
+	assert.Error(t, err)
+}
+
+func TestRollbackVersion(t *testing.T) {
+	snapshots := []*parameterSnapshot{{Reason: "snapshot"}, {Reason: "applyparameter dynamic p.yaml"},
+		{Reason: "setparameter"}}
+	version, parameterType, err := rollbackVersion(snapshots)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 2, version)
+		assert.Equal(t, "dynamic", parameterType)
+	}
+	snapshots = append(snapshots, &parameterSnapshot{Reason: parameterRollbackReason})
+	_, _, err = rollbackVersion(snapshots)
+	assert.EqualError(t, err, "Last parameter change already rolled back")
+	_, _, err = rollbackVersion(snapshots[:1])
+	assert.EqualError(t, err, "No parameter change available to roll back")
+}
+
+func TestChangedParameters(t *testing.T) {
+	a := &models.ParameterParameter{NT: 20, OPTIONS: "257", LOGGING: "OFF"}
+	b := &models.ParameterParameter{NT: 30, OPTIONS: "TRUNCATION,AUTO_EXPAND", LOGGING: "OFF"}
+	assert.Equal(t, []string{"NT"}, changedParameters(a, b))
+	assert.Empty(t, changedParameters(a, nil))
+
+	current := &models.ParameterParameter{NT: 20, USEREXITS: "3"}
+	restore := &models.ParameterParameter{NT: 20, USEREXITS: "8"}
+	changed := changedParameters(current, restore)
+	assert.Equal(t, []string{"USEREXITS"}, changed)
+	params := online_offline.NewPutAdabasParameterParams()
+	for _, v := range serverParameterSets(restore, changed) {
+		_, err := findParameter(v.name).apply(params, v.value, nil)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, "4", *params.USEREXITS)
+	_, err := snapshotVersion("3", make([]*parameterSnapshot, 2))
+	assert.EqualError(t, err, "Version 3 not valid, need version between 1 and 2")
+}
+
+func TestNucleusErrors(t *testing.T) {
+	lines := []string{"%ADANUC-I-STARTED, nucleus started", "%ADANUC-F-ABORT, nucleus run abnormally terminated",
+		"%ADANUC-E-NOTAVAIL, Container file cannot be opened"}
+	assert.Equal(t, lines[1:], nucleusErrors(lines))
+	assert.Empty(t, nucleusErrors(lines[:1]))
+	assert.Equal(t, lines[2:], newNucleusErrors(lines, 2))
+	assert.Empty(t, newNucleusErrors(lines, 3))
+	assert.Equal(t, lines[1:], newNucleusErrors(lines[:3], 5))
+}
+
+func TestParseDbids(t *testing.T) {
+	dbids, err := parseDbids("24, 25,26")
+	assert.NoError(t, err)
+	assert.Equal(t, []int{24, 25, 26}, dbids)
+	_, err = parseDbids("24,24")
+	assert.EqualError(t, err, "Database id 24 given twice")
+	_, err = parseDbids("24,x")
+	assert.Error(t, err)
+}
+
+func TestHighWaterProblems(t *testing.T) {
+	now := time.Now()
+	highWater := &models.HWMHighWater{NucleusStartTime: strfmt.DateTime(now), UserQueueSize: 100,
+		UserQueueHighWaterMark: &models.HighWaterEntries{High: 100}, ThreadSize: 8,
+		ThreadsHighWaterMark: &models.HighWaterEntries{High: 3}}
+	assert.Equal(t, []string{"User Queue exhausted, high water 100 of 100"}, highWaterProblems(highWater, now.Add(-time.Minute)))
+	assert.Len(t, highWaterProblems(highWater, now.Add(time.Minute)), 2)
+	assert.Equal(t, []string{"no high water marks available"}, highWaterProblems(nil, now))
+}
+
+func TestLingeringUsers(t *testing.T) {
+	now := time.Now()
+	details := map[int64]*models.UserQueueDetail{
+		1: {StartTransaction: strfmt.DateTime(now.Add(-time.Hour)), LastActivity: strfmt.DateTime(now.Add(-10 * time.Minute))},
+		2: {StartTransaction: strfmt.DateTime(now.Add(-time.Hour)), LastActivity: strfmt.DateTime(now)},
+		3: {LastActivity: strfmt.DateTime(now.Add(-time.Hour))},
+		4: {StartTransaction: strfmt.DateTime(now.Add(-time.Hour)), LastActivity: strfmt.DateTime(now.Add(-2 * time.Minute))},
+	}
+	assert.Equal(t, []int64{1, 4}, lingeringUsers(details, now, time.Minute))
+	assert.Equal(t, []int64{1}, lingeringUsers(details, now, 5*time.Minute))
+	assert.True(t, userGone(&online.GetUserQueueDetailBadRequest{}))
+	assert.False(t, userGone(&online.StopUserQueueEntryBadRequest{}))
+	assert.False(t, userGone(nil))
+}
+
+func TestUserFilter(t *testing.T) {
+	now := time.Now()
+	entry := &models.UserQueueEntry{UqID: 1, User: "ADMIN1", UID: &models.UserInformation{Node: "host1", Terminal: "tty1"}}
+	detail := &models.UserQueueDetail{LastActivity: strfmt.DateTime(now.Add(-time.Hour)), Files: []int64{11, 12}}
+
+	filter, _, err := parseUserFilter("user=ADM*,node=HOST1")
+	assert.NoError(t, err)
+	assert.False(t, filter.needDetail())
+	assert.True(t, filter.matches(entry, nil, now))
+	filter, _, err = parseUserFilter("idle=600,open=false,file=12")
+	assert.NoError(t, err)
+	assert.True(t, filter.needDetail())
+	assert.True(t, filter.matches(entry, detail, now))
+	filter, _, _ = parseUserFilter("open=true")
+	assert.False(t, filter.matches(entry, detail, now))
+	filter, _, _ = parseUserFilter("terminal=tty2")
+	assert.False(t, filter.matches(entry, detail, now))
+	_, _, err = parseUserFilter("idle=x")
+	assert.EqualError(t, err, "Filter idle=x not valid, need number of seconds")
+	filter, _, _ = parseUserFilter("")
+	assert.True(t, filter.empty())
+}
+
+func TestTransactionRisks(t *testing.T) {
+	now := time.Now()
+	users := []*models.UserQueueEntry{{UqID: 1}, {UqID: 2, Flags: "ET"}, {UqID: 3}, {UqID: 4, Flags: "EXU"}}
+	details := map[int64]*models.UserQueueDetail{
+		1: {LastActivity: strfmt.DateTime(now.Add(-30 * time.Second))},
+		2: {StartTransaction: strfmt.DateTime(now.Add(-270 * time.Second)), LastActivity: strfmt.DateTime(now)},
+		3: {LastActivity: strfmt.DateTime(now.Add(-30 * time.Second)), TNALimit: 40},
+		4: {LastActivity: strfmt.DateTime(now.Add(-60 * time.Second))},
+	}
+	tna := &tnaLimits{access: 600 * time.Second, et: 300 * time.Second, exclusive: 100 * time.Second}
+	risks := transactionRisks(users, details, now, 300*time.Second, tna)
+	assert.Len(t, risks, 4)
+	assert.Equal(t, int64(2), risks[0].entry.UqID)
+	assert.Equal(t, "TT", risks[0].reason)
+	assert.InDelta(t, 90, risks[0].risk, 0.1)
+	assert.Equal(t, 300*time.Second, risks[0].exceededLimit())
+	assert.Equal(t, int64(3), risks[1].entry.UqID)
+	assert.InDelta(t, 75, risks[1].risk, 0.1)
+	assert.Equal(t, int64(4), risks[2].entry.UqID)
+	assert.Equal(t, "TNAX", risks[2].reason)
+	assert.InDelta(t, 60, risks[2].risk, 0.1)
+	assert.Equal(t, 100*time.Second, risks[2].exceededLimit())
+	assert.Equal(t, "TNAA", risks[3].reason)
+	assert.InDelta(t, 5, risks[3].risk, 0.1)
+	assert.Equal(t, "ET", userType(&models.UserQueueEntry{Flags: "ET,ACTIVE"}))
+	assert.Equal(t, "EXU", userType(&models.UserQueueEntry{Flags: "exf"}))
+	assert.Equal(t, "ACC", userType(&models.UserQueueEntry{Flags: "ETX"}))
+}
+
+func TestAnalyzeLocks(t *testing.T) {
+	u1 := &models.UserInformation{ID: 1, Node: "host", Terminal: "t1"}
+	u2 := &models.UserInformation{ID: 2, Node: "host", Terminal: "t2"}
+	u3 := &models.UserInformation{ID: 3, Node: "host", Terminal: "t3"}
+	holdQueue := []*models.HoldQueueEntry{{File: 11, Isn: 5, Hid: []*models.UserInformation{u1, u3}},
+		{File: 11, Isn: 7, Hid: []*models.UserInformation{u2}}, {File: 12, Isn: 1, Hid: []*models.UserInformation{u3}}}
+	commands := []*models.CommandQueueEntry{{File: 11, Isn: 7, User: u1}, {File: 11, Isn: 5, User: u2},
+		{File: 11, Isn: 5, User: u1}, {File: 12, Isn: 9, User: u2}}
+	analysis := analyzeLocks(holdQueue, commands)
+	assert.Len(t, analysis.records, 3)
+	assert.Equal(t, []string{"host/t1/1", "host/t3/3"}, analysis.records[0].holders)
+	assert.Equal(t, []string{"host/t2/2"}, analysis.records[0].waiters)
+	assert.Equal(t, []string{"host/t1/1"}, analysis.records[1].waiters)
+	assert.Equal(t, [][]string{{"host/t1/1", "host/t2/2"}}, analysis.deadlocks())
+
+	chain := &lockAnalysis{waitFor: make(map[string][]string)}
+	for i := 0; i < 200; i++ {
+		for j := i + 1; j < 200 && j < i+4; j++ {
+			chain.waitFor[strconv.Itoa(1000+i)] = append(chain.waitFor[strconv.Itoa(1000+i)], strconv.Itoa(1000+j))
+		}
+	}
+	assert.Empty(t, chain.deadlocks())
+	chain.waitFor["1199"] = []string{"1000"}
+	assert.Len(t, chain.deadlocks(), 1)
+	hot := analysis.hotIsns()
+	assert.Equal(t, int64(5), hot[11][0].key.isn)
+	assert.Len(t, hot[12], 1)
+}
+
+func TestProfile(t *testing.T) {
+	start := time.Now()
+	u1 := &models.UserInformation{ID: 1, Node: "host", Terminal: "t1"}
+	samples := []*profileSample{
+		{time: start, threads: []*models.Thread{{CommandCode: "L3", File: 11}, {CommandCode: ""}},
+			commands: []*models.CommandQueueEntry{{CommandCode: "L3", File: 11, User: u1}}},
+		{time: start.Add(time.Second), threads: []*models.Thread{{CommandCode: "L3", File: 11}, {CommandCode: "E1", File: 12}}},
+		{time: start.Add(2 * time.Second), threads: []*models.Thread{{CommandCode: "E1", File: 12}, {CommandCode: "E1", File: 12}}},
+	}
+	ranking := rankProfile(samples)
+	assert.Equal(t, 5, ranking.threadSamples)
+	assert.Equal(t, 1, ranking.commandSamples)
+	assert.Equal(t, "E1", ranking.threadCommand[0].name)
+	assert.Equal(t, 3, ranking.threadCommand[0].count)
+	assert.Equal(t, "host/t1/1", ranking.queueUser[0].name)
+	histogram := profileHistogram(samples, 2)
+	assert.Len(t, histogram, 2)
+	assert.InDelta(t, 1.5, histogram[0].threads, 0.01)
+	assert.InDelta(t, 0.5, histogram[0].commands, 0.01)
+	assert.InDelta(t, 2, histogram[1].threads, 0.01)
+	assert.EqualError(t, Profile(nil, 1, "interval=1e-10", nil), "Parameter interval need at least 10ms")
+}
+
+func TestCommandHistory(t *testing.T) {
+	start := time.Now().Add(-time.Hour)
+	samples := []*commandSample{{Time: start, Counts: map[string]int64{"L3": 1000, "E1": 100}}}
+	counts := map[string]int64{"L3": 1000, "E1": 100}
+	for i := 1; i <= 6; i++ {
+		counts["L3"] += 600
+		counts["E1"] += 60
+		if i == 4 {
+			counts["E1"] += 6000
+		}
+		c := map[string]int64{"L3": counts["L3"], "E1": counts["E1"]}
+		samples = append(samples, &commandSample{Time: start.Add(time.Duration(i) * time.Minute), Counts: c})
+	}
+	samples = append(samples, &commandSample{Time: start.Add(7 * time.Minute), Counts: map[string]int64{"L3": 600, "E1": 60}})
+
+	intervals := commandIntervals(samples)
+	assert.Len(t, intervals, 7)
+	assert.Equal(t, int64(600), intervals[0].counts["L3"])
+	assert.Equal(t, int64(6060), intervals[3].counts["E1"])
+	assert.Equal(t, int64(600), intervals[6].counts["L3"])
+	totals, seconds := commandMix(intervals, start, start.Add(2*time.Minute))
+	assert.Equal(t, int64(1200), totals["L3"])
+	assert.InDelta(t, 120, seconds, 0.01)
+	spikes := detectSpikes(intervals, 5, 100)
+	assert.Len(t, spikes, 1)
+	assert.Equal(t, "E1", spikes[0].command)
+	assert.InDelta(t, 101, spikes[0].rate, 0.01)
+}
+
+func TestBufferpoolAdvisor(t *testing.T) {
+	rate, ok := hitRate(0, 0)
+	assert.False(t, ok)
+	assert.Equal(t, 0.0, rate)
+	rate, ok = hitRate(1000, 50)
+	assert.True(t, ok)
+	assert.InDelta(t, 95, rate, 0.01)
+	assert.Equal(t, 0.0, percentOf(10, 0))
+
+	start := time.Now()
+	samples := []*bufferpoolSample{{Time: start, Size: 1000, LogicalReads: 1000, PhysicalReads: 100},
+		{Time: start.Add(time.Hour), Size: 1000, LogicalReads: 2000, PhysicalReads: 200},
+		{Time: start.Add(2 * time.Hour), Size: 1000, LogicalReads: 1000, PhysicalReads: 100},
+		{Time: start.Add(3 * time.Hour), Size: 2000, LogicalReads: 5000, PhysicalReads: 100}}
+	points := bufferpoolHitRates(samples, 1000)
+	assert.Len(t, points, 2)
+	assert.InDelta(t, 90, points[0].value, 0.01)
+	assert.InDelta(t, 90, points[1].value, 0.01)
+	samples = append(samples, &bufferpoolSample{Time: start.Add(4 * time.Hour), Size: 2000, LogicalReads: 15000, PhysicalReads: 200})
+	resized := bufferpoolHitRates(samples, 2000)
+	assert.Len(t, resized, 1)
+	assert.InDelta(t, 99, resized[0].value, 0.01)
+
+	lbp, _ := adviseLBP(1000, 900, points, 98)
+	assert.Equal(t, int64(2000), lbp)
+	lbp, _ = adviseLBP(1000, 900, points, 92)
+	assert.Equal(t, int64(1500), lbp)
+	lbp, _ = adviseLBP(1000, 400, []trendPoint{{0, 99}, {1, 99}}, 95)
+	assert.Equal(t, int64(500), lbp)
+	lbp, _ = adviseLBP(1000, 900, []trendPoint{{0, 99}, {1, 99}}, 95)
+	assert.Equal(t, int64(1000), lbp)
+	lbp, _ = adviseLBP(1000, 900, []trendPoint{{0, 99}, {1, 98}}, 95)
+	assert.Equal(t, int64(1250), lbp)
+}
+
+func TestActivityIntervals(t *testing.T) {
+	first := &models.ActivityStatsStatistics{ThbWaitIsn: 10, ThbEtSync: 5, WpSpaceWaitTotal: 2}
+	second := &models.ActivityStatsStatistics{ThbWaitIsn: 40, ThbEtSync: 5, WpSpaceWaitTotal: 3}
+	deltas := activityDeltas(first, second)
+	assert.Equal(t, []int64{0, 30, 0, 0, 1}, deltas)
+	restarted := &models.ActivityStatsStatistics{ThbWaitIsn: 4, ThbEtSync: 6}
+	assert.Equal(t, []int64{0, 4, 6, 0, 0}, activityDeltas(second, restarted))
+
+	start := time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC)
+	intervals := []*activityInterval{{start: start, end: start.Add(time.Minute), deltas: deltas},
+		{start: start.Add(time.Minute), end: start.Add(2 * time.Minute), deltas: []int64{0, 50, 0, 0, 0}}}
+	guidance := activityGuidance(intervals)
+	assert.Len(t, guidance, 2)
+	assert.Contains(t, guidance[0], "ISN: 80, peak 50 at 10:02:00")
+	assert.Contains(t, guidance[0], "NISNHQ")
+	assert.Contains(t, guidance[1], "LWP")
+	assert.Empty(t, activityGuidance([]*activityInterval{{deltas: []int64{0, 0, 0, 0, 0}}}))
+}
+
+func TestUcbFilter(t *testing.T) {
+	now := time.Now()
+	old := &models.UCBEntry{ID: "ADAORD", DBMode: "EXU", Sequence: 1, Date: strfmt.DateTime(now.Add(-10 * 24 * time.Hour)),
+		UcbFiles: []*models.UCBEntryUcbFilesItems0{{UcbFile: 1}, {UcbFile: 3}, {UcbFile: 4}, {UcbFile: 5}, {UcbFile: 9}}}
+	recent := &models.UCBEntry{ID: "ADALOD", DBMode: "ACC", Sequence: 2, Date: strfmt.DateTime(now.Add(-time.Hour)),
+		UcbFiles: []*models.UCBEntryUcbFilesItems0{{UcbFile: 11}}}
+	assert.Equal(t, "1,3-5,9", ucbFileList(old))
+	assert.Equal(t, "11", ucbFileList(recent))
+	assert.Equal(t, "-", ucbFileList(&models.UCBEntry{}))
+
+	filter, _, err := parseUcbFilter("utility=ADA*,age=7")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.True(t, filter.matches(old, now))
+	assert.False(t, filter.matches(recent, now))
+	assert.False(t, filter.matches(&models.UCBEntry{ID: "ADAORD"}, now))
+	filter, _, _ = parseUcbFilter("mode=acc,file=11")
+	assert.False(t, filter.matches(old, now))
+	assert.True(t, filter.matches(recent, now))
+	filter, _, _ = parseUcbFilter("")
+	assert.True(t, filter.empty())
+	_, _, err = parseUcbFilter("age=x")
+	assert.Error(t, err)
+	_, _, err = parseUcbFilter("confirm=yes")
+	assert.Error(t, err)
+
+	server := &models.UCBEntry{}
+	err = json.Unmarshal([]byte(`{"Date":"2020-03-01T10:15:00.000Z","DBMode":"EXU","Id":"ADAORD","Sequence":7,`+
+		`"ucbFiles":[{"UcbFile":3},{"UcbFile":4}]}`), server)
+	if assert.NoError(t, err) {
+		filter, _, _ = parseUcbFilter("utility=ADAORD,age=7")
+		assert.True(t, filter.matches(server, time.Date(2020, 3, 9, 0, 0, 0, 0, time.UTC)))
+		assert.False(t, filter.matches(server, time.Date(2020, 3, 5, 0, 0, 0, 0, time.UTC)))
+		assert.Equal(t, "3-4", ucbFileList(server))
+	}
+}
@@ -0,0 +1,182 @@
+/*
+* Copyright © 2018 Software AG, Darmstadt, Germany and/or its licensors
+*
+* SPDX-License-Identifier: Apache-2.0
+*
+*   Licensed under the Apache License, Version 2.0 (the "License");
+*   you may not use this file except in compliance with the License.
+*   You may obtain a copy of the License at
+*
+*       http://www.apache.org/licenses/LICENSE-2.0
+*
+*   Unless required by applicable law or agreed to in writing, software
+*   distributed under the License is distributed on an "AS IS" BASIS,
+*   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*   See the License for the specific language governing permissions and
+*   limitations under the License.
+*
+ */
+
+package database
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-openapi/runtime"
+	"softwareag.com/client"
+	"softwareag.com/models"
+)
+
+// openTransaction check if the user has an open transaction
+func openTransaction(detail *models.UserQueueDetail) bool {
+	return detail != nil && !time.Time(detail.StartTransaction).IsZero()
+}
+
+// lingeringUsers user queue ids of the users with an open transaction but without activity
+// since the given idle time
+func lingeringUsers(details map[int64]*models.UserQueueDetail, now time.Time, idle time.Duration) []int64 {
+	ids := make([]int64, 0)
+	for id, d := range details {
+		if openTransaction(d) && now.Sub(time.Time(d.LastActivity)) >= idle {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// userDetails read all users in the user queue with their details. Users leaving the user queue
+// while the details are read are skipped.
+func userDetails(clientInstance *client.AdabasAdmin, dbid int, auth runtime.ClientAuthInfoWriter) ([]*models.UserQueueEntry,
+	map[int64]*models.UserQueueDetail, error) {
+	users, err := getUserQueue(clientInstance, dbid, auth)
+	if err != nil {
+		return nil, nil, err
+	}
+	present := make([]*models.UserQueueEntry, 0, len(users))
+	details := make(map[int64]*models.UserQueueDetail)
+	for _, u := range users {
+		d, err := readUserDetail(clientInstance, dbid, u.UqID, auth)
+		if userGone(err) {
+			continue
+		}
+		if err != nil {
+			fmt.Println("Error:", err)
+			return nil, nil, err
+		}
+		present = append(present, u)
+		details[u.UqID] = d
+	}
+	return present, details, nil
+}
+
+// drainSeconds parse a drain time in seconds
+func drainSeconds(values map[string]string, key string, defaultValue time.Duration) (time.Duration, error) {
+	v, ok := values[key]
+	if !ok {
+		return defaultValue, nil
+	}
+	seconds, err := strconv.Atoi(v)
+	if err != nil || seconds < 0 {
+		return 0, fmt.Errorf("Parameter %s=%s not valid, need number of seconds", key, v)
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
+
+// DrainShutdown shutdown the database after the open transactions are finished. The parameter defines
+// the grace period in seconds to wait for open transactions, stopidle=true stops users with open
+// transactions idle for idle seconds after the grace period, and the hard deadline in seconds after
+// which the shutdown is cancelled, like grace=300,stopidle=true,idle=60,deadline=600.
+func DrainShutdown(clientInstance *client.AdabasAdmin, dbid int, param string, auth runtime.ClientAuthInfoWriter) error {
+	if dbid < 1 {
+		fmt.Println("Please add option -dbid Adabas database id")
+		return fmt.Errorf("Please add option -dbid Adabas database id")
+	}
+	values, err := parseKeyValues(param, "grace", "stopidle", "idle", "deadline")
+	if err != nil {
+		fmt.Println(err.Error())
+		return err
+	}
+	grace, err := drainSeconds(values, "grace", 300*time.Second)
+	var idle, deadline time.Duration
+	if err == nil {
+		idle, err = drainSeconds(values, "idle", 60*time.Second)
+	}
+	if err == nil {
+		deadline, err = drainSeconds(values, "deadline", grace+300*time.Second)
+	}
+	if err == nil && deadline < grace {
+		err = fmt.Errorf("Parameter deadline need to be larger than grace")
+	}
+	if err != nil {
+		fmt.Println(err.Error())
+		return err
+	}
+	stopIdle := false
+	if v, ok := values["stopidle"]; ok {
+		stopIdle, err = strconv.ParseBool(v)
+		if err != nil {
+			fmt.Println("Parameter stopidle need true or false")
+			return fmt.Errorf("Parameter stopidle need true or false")
+		}
+	}
+
+	begin := time.Now()
+	fmt.Printf("\nDrain database %03d, waiting up to %v for open transactions\n", dbid, grace)
+	for {
+		_, details, err := userDetails(clientInstance, dbid, auth)
+		if err != nil {
+			return err
+		}
+		commands, err := getCommandQueue(clientInstance, dbid, auth)
+		if err != nil {
+			return err
+		}
+		open := 0
+		for _, d := range details {
+			if openTransaction(d) {
+				open++
+			}
+		}
+		fmt.Printf("%s %d users, %d open transactions, %d commands in queue\n", time.Now().Format("15:04:05"),
+			len(details), open, len(commands))
+		if open == 0 && len(commands) == 0 {
+			break
+		}
+		if time.Since(begin) >= grace {
+			fmt.Printf("Grace period of %v expired\n", grace)
+			if stopIdle {
+				for _, id := range lingeringUsers(details, time.Now(), idle) {
+					fmt.Printf("Stop user %d idle with open transaction\n", id)
+					if _, err = stopUserIfPresent(clientInstance, dbid, id, auth); err != nil {
+						return err
+					}
+				}
+			}
+			break
+		}
+		time.Sleep(waitInterval)
+	}
+
+	err = Operation(clientInstance, dbid, "shutdown", auth)
+	if err != nil {
+		return err
+	}
+	remaining := deadline - time.Since(begin)
+	if remaining < waitInterval {
+		remaining = waitInterval
+	}
+	err = waitState(clientInstance, dbid, false, remaining, auth)
+	if err != ErrWaitTimeout {
+		return err
+	}
+	fmt.Printf("Hard deadline of %v reached, cancel database %03d\n", deadline, dbid)
+	err = Operation(clientInstance, dbid, "cancel", auth)
+	if err != nil {
+		return err
+	}
+	return waitState(clientInstance, dbid, false, time.Minute, auth)
+}
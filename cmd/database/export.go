@@ -0,0 +1,294 @@
+/*
+* Copyright © 2018 Software AG, Darmstadt, Germany and/or its licensors
+*
+* SPDX-License-Identifier: Apache-2.0
+*
+*   Licensed under the Apache License, Version 2.0 (the "License");
+*   you may not use this file except in compliance with the License.
+*   You may obtain a copy of the License at
+*
+*       http://www.apache.org/licenses/LICENSE-2.0
+*
+*   Unless required by applicable law or agreed to in writing, software
+*   distributed under the License is distributed on an "AS IS" BASIS,
+*   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*   See the License for the specific language governing permissions and
+*   limitations under the License.
+*
+ */
+
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/go-openapi/runtime"
+	"softwareag.com/client"
+	"softwareag.com/client/online_offline"
+	"softwareag.com/client/scheduler"
+	"softwareag.com/models"
+)
+
+// Export export the database definition into a directory
+func Export(clientInstance *client.AdabasAdmin, dbid int, directory string, auth runtime.ClientAuthInfoWriter) error {
+	if dbid < 1 {
+		fmt.Println("Please add option -dbid Adabas database id")
+		return fmt.Errorf("Please add option -dbid Adabas database id")
+	}
+	if directory == "" {
+		directory = fmt.Sprintf("export-%03d", dbid)
+	}
+	if err := os.MkdirAll(directory, 0755); err != nil {
+		fmt.Println("Error creating export directory:", err)
+		return err
+	}
+	fmt.Printf("\nExport database %03d into %s\n\n", dbid, directory)
+
+	database, err := exportDatabase(clientInstance, dbid, auth)
+	if err != nil {
+		return err
+	}
+	err = writeJSON(filepath.Join(directory, "create-database.json"), database)
+	if err != nil {
+		return err
+	}
+
+	for _, t := range []string{"static", "dynamic"} {
+		parameter, perr := getParameter(clientInstance, dbid, t, auth)
+		if perr != nil {
+			return perr
+		}
+		err = writeJSON(filepath.Join(directory, "parameter-"+t+".json"), parameter)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = exportFiles(clientInstance, dbid, directory, auth)
+	if err != nil {
+		return err
+	}
+	return exportJobs(clientInstance, dbid, directory, auth)
+}
+
+// exportDatabase generate the database creation definition out of the GCB and the container
+func exportDatabase(clientInstance *client.AdabasAdmin, dbid int, auth runtime.ClientAuthInfoWriter) (*models.Database, error) {
+	gcbParams := online_offline.NewGetDatabaseGcbParams()
+	gcbParams.Dbid = float64(dbid)
+	gcbResp, err := clientInstance.OnlineOffline.GetDatabaseGcb(gcbParams, auth)
+	if err != nil {
+		switch err.(type) {
+		case *online_offline.GetDatabaseGcbBadRequest:
+			response := err.(*online_offline.GetDatabaseGcbBadRequest)
+			fmt.Println(response.Payload.Error.Code, ":", response.Payload.Error.Message)
+		default:
+			fmt.Println("Error:", err)
+		}
+		return nil, err
+	}
+	params := online_offline.NewGetDatabaseContainerParams()
+	params.Dbid = float64(dbid)
+	resp, err := clientInstance.OnlineOffline.GetDatabaseContainer(params, auth)
+	if err != nil {
+		switch err.(type) {
+		case *online_offline.GetDatabaseContainerBadRequest:
+			response := err.(*online_offline.GetDatabaseContainerBadRequest)
+			fmt.Println(response.Payload.Error.Code, ":", response.Payload.Error.Message)
+		default:
+			fmt.Println("Error:", err)
+		}
+		return nil, err
+	}
+
+	gcb := gcbResp.Payload.Gcb
+	database := &models.Database{Dbid: gcb.Dbid, Name: gcb.Name,
+		CheckpointFile: gcb.CheckpointFile, SecurityFile: gcb.SecurityFile, UserFile: gcb.ETDataFile}
+
+	// Database creation expect the container list in the order ASSO1, ASSO2, DATA1 and WORK
+	asso := make([]*models.ContainerInfo, 0)
+	data := make([]*models.ContainerInfo, 0)
+	work := make([]*models.ContainerInfo, 0)
+	for _, c := range resp.Payload.Container.ContainerList {
+		switch strings.ToUpper(c.Type) {
+		case "ASSO":
+			asso = append(asso, c)
+		case "DATA":
+			data = append(data, c)
+		case "WORK":
+			work = append(work, c)
+		}
+	}
+	if len(asso) == 0 || len(data) == 0 || len(work) == 0 {
+		fmt.Println("Database container incomplete, need ASSO, DATA and WORK container")
+		return nil, fmt.Errorf("Database container incomplete")
+	}
+	if len(asso) < 2 {
+		fmt.Printf("Database %03d has only one ASSO container, the database creation need ASSO1 and ASSO2\n", dbid)
+		return nil, fmt.Errorf("Database %03d has only one ASSO container, the database creation need ASSO1 and ASSO2", dbid)
+	}
+	database.ContainerList = append(database.ContainerList, exportContainer(asso[0]))
+	database.ContainerList = append(database.ContainerList, exportContainer(asso[1]))
+	database.ContainerList = append(database.ContainerList, exportContainer(data[0]))
+	database.ContainerList = append(database.ContainerList, exportContainer(work[0]))
+	additional := data[1:]
+	if len(asso) > 2 {
+		additional = append(additional, asso[2:]...)
+	}
+	for _, c := range additional {
+		fmt.Printf("Additional container %s%d %s need to be added after creation\n", c.Type, c.ContainerNumber, c.Path)
+	}
+	return database, nil
+}
+
+func exportContainer(c *models.ContainerInfo) *models.Container {
+	return &models.Container{BlockSize: fmt.Sprintf("%d%s", c.BlockSize, c.BlockUnit),
+		ContainerSize: fmt.Sprintf("%d%s", c.Size, c.SizeUnit), Path: c.Path}
+}
+
+// exportFiles export FDU and FDT of all database files, the file sizes are exported in blocks.
+// Referential constraints are exported as ADAINV job of the file.
+func exportFiles(clientInstance *client.AdabasAdmin, dbid int, directory string, auth runtime.ClientAuthInfoWriter) error {
+	files, err := getFiles(clientInstance, dbid, auth)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		fcb, ferr := getFcb(clientInstance, dbid, f.FileNr, auth)
+		if ferr != nil {
+			return ferr
+		}
+		fdtDefinition, ferr := getFdt(clientInstance, dbid, int(f.FileNr), auth)
+		if ferr != nil {
+			return ferr
+		}
+
+		fdu := &models.FduFdt{FileNumber: f.FileNr, MaxIsn: fcb.MaxIsn}
+		fdu.FduOptions = &models.FduFdtFduOptions{FduName: fcb.Name,
+			FduDSSize: fcb.TotalDsBlocks, FduDSMUnitDS: fduUnitBlocks,
+			FduNISize: fcb.TotalNiBlocks, FduDSMUnitNI: fduUnitBlocks,
+			FduUISize: fcb.TotalUIBlocks, FduDSMUnitUI: fduUnitBlocks,
+			FduAssoPfac: fcb.PaddingFactorAsso, FduDataPfac: fcb.PaddingFactorData,
+			FduMaxRecordLength: fcb.MaxRecordLength, FduLobFile: fcb.LobFile}
+		base := filepath.Join(directory, fmt.Sprintf("file-%03d", f.FileNr))
+		err = writeJSON(base+".json", fdu)
+		if err != nil {
+			return err
+		}
+		fdt := fmt.Sprintf("; FDT of database %03d file %03d %s\n", dbid, f.FileNr, fcb.Name) +
+			fdtDefinitionOf(fdtDefinition).source()
+		err = ioutil.WriteFile(base+".fdt", []byte(fdt), 0644)
+		if err != nil {
+			fmt.Println("Error writing FDT:", err)
+			return err
+		}
+		fmt.Printf("Exported file %03d %s\n", f.FileNr, fcb.Name)
+		if job := referentialJob(dbid, f.FileNr, fdtDefinition.Referentials); job != nil {
+			err = writeJSON(filepath.Join(directory, "job-"+job.Job.Name+".json"), job)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Exported referential constraints of file %03d as job %s\n", f.FileNr, job.Job.Name)
+		}
+	}
+	return nil
+}
+
+// referentialJob generate the ADAINV job creating the referential constraints of the foreign file.
+// The referential contains the primary file in the length, the primary field in the format,
+// the foreign field as sub field and the update and delete rules in the flags.
+func referentialJob(dbid int, fnr int64, referentials []*models.Field) *models.JobParameter {
+	job := &models.JobParameter{Job: &models.JobDescription{Name: fmt.Sprintf("refint-%03d-%03d", dbid, fnr),
+		Description: fmt.Sprintf("Referential constraints of database %03d file %03d", dbid, fnr),
+		Utility:     "ADAINV"}}
+	job.Job.Parameters = append(job.Job.Parameters,
+		&models.JobDescriptionParametersItems0{Parameter: fmt.Sprintf("db=%d", dbid)},
+		&models.JobDescriptionParametersItems0{Parameter: fmt.Sprintf("file=%d", fnr)})
+	for _, r := range referentials {
+		if strings.ToUpper(r.Type) == "PRIMARY" || len(r.SubFields) == 0 {
+			continue
+		}
+		refint := fmt.Sprintf("refint=%s,%d,%s,%s", r.Name, r.Length, r.Format, r.SubFields[0].SubName)
+		for _, rule := range strings.FieldsFunc(strings.ToUpper(r.Flags), func(c rune) bool {
+			return c == ',' || c == ' ' || c == '|'
+		}) {
+			refint += "," + rule
+		}
+		job.Job.Parameters = append(job.Job.Parameters, &models.JobDescriptionParametersItems0{Parameter: refint})
+	}
+	if len(job.Job.Parameters) == 2 {
+		return nil
+	}
+	return job
+}
+
+// exportJobs export all scheduler jobs referencing the database
+func exportJobs(clientInstance *client.AdabasAdmin, dbid int, directory string, auth runtime.ClientAuthInfoWriter) error {
+	params := scheduler.NewGetJobsParams()
+	resp, err := clientInstance.Scheduler.GetJobs(params, auth)
+	if err != nil {
+		switch err.(type) {
+		case *scheduler.GetJobsNotFound:
+			response := err.(*scheduler.GetJobsNotFound)
+			fmt.Println(response.Error())
+		default:
+			fmt.Println("Error:", err)
+		}
+		return err
+	}
+	for _, j := range resp.Payload.JobDefinition {
+		if j.Job == nil || !jobReferencesDatabase(j.Job, dbid) {
+			continue
+		}
+		job := &models.JobParameter{Job: &models.JobDescription{Name: j.Job.Name, User: j.Job.User,
+			Description: j.Job.Description, Utility: j.Job.Utility, Script: j.Job.Script}}
+		for _, p := range j.Job.Parameters {
+			job.Job.Parameters = append(job.Job.Parameters, &models.JobDescriptionParametersItems0{Parameter: p.Parameter})
+		}
+		for _, e := range j.Job.Environments {
+			job.Job.Environments = append(job.Job.Environments, &models.JobDescriptionEnvironmentsItems0{Parameter: e.Parameter})
+		}
+		err = writeJSON(filepath.Join(directory, "job-"+j.Job.Name+".json"), job)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Exported job %s\n", j.Job.Name)
+	}
+	return nil
+}
+
+// jobReferencesDatabase check if the job parameter reference the database
+func jobReferencesDatabase(job *models.Job, dbid int) bool {
+	for _, p := range job.Parameters {
+		v := strings.SplitN(p.Parameter, "=", 2)
+		if len(v) != 2 {
+			continue
+		}
+		switch strings.ToLower(strings.TrimSpace(v[0])) {
+		case "db", "dbid":
+			if d, err := strconv.Atoi(strings.TrimSpace(v[1])); err == nil && d == dbid {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func writeJSON(fileName string, v interface{}) error {
+	raw, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		fmt.Println("Error generating JSON:", err)
+		return err
+	}
+	err = ioutil.WriteFile(fileName, raw, 0644)
+	if err != nil {
+		fmt.Println("Error writing file:", err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,742 @@
+/*
+* Copyright © 2018 Software AG, Darmstadt, Germany and/or its licensors
+*
+* SPDX-License-Identifier: Apache-2.0
+*
+*   Licensed under the Apache License, Version 2.0 (the "License");
+*   you may not use this file except in compliance with the License.
+*   You may obtain a copy of the License at
+*
+*       http://www.apache.org/licenses/LICENSE-2.0
+*
+*   Unless required by applicable law or agreed to in writing, software
+*   distributed under the License is distributed on an "AS IS" BASIS,
+*   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*   See the License for the specific language governing permissions and
+*   limitations under the License.
+*
+ */
+
+package database
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"softwareag.com/models"
+)
+
+// fdtField field entry of the FDT source, groups contain their sub fields
+type fdtField struct {
+	level    int
+	name     string
+	length   int
+	format   string
+	options  []string
+	line     int
+	children []*fdtField
+}
+
+// fdtParent parent field reference of a descriptor, optional with byte range
+type fdtParent struct {
+	name string
+	from int
+	to   int
+}
+
+// fdtDescriptor special descriptor entry of the FDT source
+type fdtDescriptor struct {
+	kind    string
+	number  int
+	name    string
+	length  int
+	format  string
+	options []string
+	parents []fdtParent
+	line    int
+}
+
+// fdtDefinition parsed FDT source, referentials are only available for the FDT of a file
+type fdtDefinition struct {
+	fields       []*fdtField
+	descriptors  []*fdtDescriptor
+	names        map[string]*fdtField
+	referentials map[string]string
+}
+
+// fdtError FDT syntax or validation error
+type fdtError struct {
+	line    int
+	column  int
+	message string
+}
+
+func (e *fdtError) Error() string {
+	return fmt.Sprintf("line %d column %d: %s", e.line, e.column, e.message)
+}
+
+// fdtErrors list of all errors found in the FDT source
+type fdtErrors []*fdtError
+
+func (e fdtErrors) Error() string {
+	var buffer bytes.Buffer
+	for i, err := range e {
+		if i > 0 {
+			buffer.WriteString("\n")
+		}
+		buffer.WriteString(err.Error())
+	}
+	return buffer.String()
+}
+
+// fdtToken part of a FDT line with its column
+type fdtToken struct {
+	text   string
+	column int
+}
+
+// fdtParser parser state of the FDT source
+type fdtParser struct {
+	definition *fdtDefinition
+	errors     fdtErrors
+	line       int
+	groups     []*fdtField
+	seen       map[string]int
+}
+
+// isGroup check if the field is a group field
+func (f *fdtField) isGroup() bool {
+	return f.format == ""
+}
+
+// hasOption check if the option is set
+func (f *fdtField) hasOption(option string) bool {
+	return containsOption(f.options, option)
+}
+
+// hasOption check if the option is set
+func (d *fdtDescriptor) hasOption(option string) bool {
+	return containsOption(d.options, option)
+}
+
+func containsOption(options []string, option string) bool {
+	for _, o := range options {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
+
+// field search the field with the given name
+func (d *fdtDefinition) field(name string) *fdtField {
+	return d.names[name]
+}
+
+// descriptor search the special descriptor with the given name
+func (d *fdtDefinition) descriptor(name string) *fdtDescriptor {
+	for _, s := range d.descriptors {
+		if s.name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+// allFields list all fields with the group fields followed by their sub fields
+func (d *fdtDefinition) allFields() []*fdtField {
+	list := make([]*fdtField, 0)
+	var walk func(fields []*fdtField)
+	walk = func(fields []*fdtField) {
+		for _, f := range fields {
+			list = append(list, f)
+			walk(f.children)
+		}
+	}
+	walk(d.fields)
+	return list
+}
+
+// source generate the FDT source line of the field
+func (f *fdtField) source() string {
+	line := fmt.Sprintf("%d,%s", f.level, f.name)
+	if !f.isGroup() {
+		line += fmt.Sprintf(",%d,%s", f.length, f.format)
+	}
+	for _, o := range f.options {
+		line += "," + o
+	}
+	return line
+}
+
+// source generate the FDT source line of the special descriptor
+func (d *fdtDescriptor) source() string {
+	parents := make([]string, 0)
+	for _, p := range d.parents {
+		if p.from == 0 && p.to == 0 {
+			parents = append(parents, p.name)
+		} else {
+			parents = append(parents, fmt.Sprintf("%s(%d,%d)", p.name, p.from, p.to))
+		}
+	}
+	options := ""
+	for _, o := range d.options {
+		options += "," + o
+	}
+	switch d.kind {
+	case "PHONDE":
+		return fmt.Sprintf("PHONDE,%s(%s)", d.name, strings.Join(parents, ","))
+	case "HYPDE":
+		return fmt.Sprintf("HYPDE,%d,%s,%d,%s%s=%s", d.number, d.name, d.length, d.format, options, strings.Join(parents, ","))
+	case "COLDE":
+		return fmt.Sprintf("COLDE,%d,%s%s=%s", d.number, d.name, options, strings.Join(parents, ","))
+	}
+	return fmt.Sprintf("%s,%s%s=%s", d.kind, d.name, options, strings.Join(parents, ","))
+}
+
+// source generate the FDT source of all fields and special descriptors, referentials have
+// no FDT source syntax and are added as comment
+func (d *fdtDefinition) source() string {
+	var buffer bytes.Buffer
+	for _, f := range d.allFields() {
+		buffer.WriteString(f.source() + "\n")
+	}
+	for _, s := range d.descriptors {
+		switch s.kind {
+		case "SUPDE", "SUBDE", "PHONDE", "HYPDE", "COLDE":
+			buffer.WriteString(s.source() + "\n")
+		default:
+			buffer.WriteString(fmt.Sprintf("; unsupported descriptor %s type %s\n", s.name, s.kind))
+		}
+	}
+	for _, n := range sortedKeys(d.referentials) {
+		buffer.WriteString(fmt.Sprintf("; referential %s not exported\n", n))
+	}
+	return buffer.String()
+}
+
+// fdtDefinitionOf convert the field definition table of a file into the FDT definition
+func fdtDefinitionOf(fdt *models.FdtFDT) *fdtDefinition {
+	definition := &fdtDefinition{names: make(map[string]*fdtField), referentials: make(map[string]string)}
+	groups := make([]*fdtField, 0)
+	for _, f := range fdt.Fields {
+		field := &fdtField{level: int(f.Level), name: f.Name, length: int(f.Length), format: f.Format,
+			options: fieldOptions(f.Flags)}
+		if field.level < 1 || field.level > len(groups)+1 {
+			field.level = len(groups) + 1
+		}
+		groups = groups[:field.level-1]
+		if field.level == 1 {
+			definition.fields = append(definition.fields, field)
+		} else {
+			groups[field.level-2].children = append(groups[field.level-2].children, field)
+		}
+		if field.isGroup() {
+			groups = append(groups, field)
+		}
+		definition.names[field.name] = field
+	}
+	kinds := map[string]string{"SUPER": "SUPDE", "SUB": "SUBDE", "PHONETIC": "PHONDE", "HYPER": "HYPDE", "COLLATION": "COLDE"}
+	for _, f := range fdt.Descriptors {
+		descriptor := &fdtDescriptor{kind: kinds[f.Type], name: f.Name, length: int(f.Length), format: f.Format}
+		if descriptor.kind == "" {
+			descriptor.kind = f.Type
+		}
+		if descriptor.kind == "HYPDE" || descriptor.kind == "COLDE" {
+			descriptor.number = int(f.Level)
+		}
+		if descriptor.kind != "HYPDE" {
+			descriptor.length = 0
+			descriptor.format = ""
+		}
+		for _, o := range fieldOptions(f.Flags) {
+			if o != "DE" {
+				descriptor.options = append(descriptor.options, o)
+			}
+		}
+		for _, s := range f.SubFields {
+			descriptor.parents = append(descriptor.parents, fdtParent{name: s.SubName, from: int(s.From), to: int(s.To)})
+		}
+		definition.descriptors = append(definition.descriptors, descriptor)
+	}
+	for _, r := range fdt.Referentials {
+		definition.referentials[r.Name] = fmt.Sprintf("%s %d %s %s", r.Type, r.Length, r.Format, r.Flags)
+	}
+	return definition
+}
+
+// parseFdt parse and validate the FDT source, all errors found are returned with line and column
+func parseFdt(source string) (*fdtDefinition, error) {
+	parser := newFdtParser()
+	return parser.parse(source)
+}
+
+// parseFdtAddition parse and validate FDT source adding fields to the existing field definition table
+func parseFdtAddition(source string, existing *models.FdtFDT) (*fdtDefinition, error) {
+	parser := newFdtParser()
+	for _, fields := range [][]*models.Field{existing.Fields, existing.Descriptors} {
+		for _, f := range fields {
+			parser.seen[f.Name] = 0
+			if f.Type == "FIELD" || f.Type == "" {
+				parser.definition.names[f.Name] = &fdtField{level: int(f.Level), name: f.Name, length: int(f.Length),
+					format: f.Format, options: fieldOptions(f.Flags)}
+			}
+		}
+	}
+	return parser.parse(source)
+}
+
+func newFdtParser() *fdtParser {
+	return &fdtParser{definition: &fdtDefinition{names: make(map[string]*fdtField)},
+		seen: make(map[string]int)}
+}
+
+func (parser *fdtParser) parse(source string) (*fdtDefinition, error) {
+	for i, line := range strings.Split(strings.Replace(source, "\r", "", -1), "\n") {
+		parser.line = i + 1
+		if c := strings.IndexByte(line, ';'); c >= 0 {
+			line = line[:c]
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		parser.parseLine(line)
+	}
+	parser.checkGroups(parser.definition.fields)
+	parser.checkDescriptors()
+	if len(parser.errors) > 0 {
+		sort.SliceStable(parser.errors, func(i, j int) bool {
+			if parser.errors[i].line == parser.errors[j].line {
+				return parser.errors[i].column < parser.errors[j].column
+			}
+			return parser.errors[i].line < parser.errors[j].line
+		})
+		return nil, parser.errors
+	}
+	return parser.definition, nil
+}
+
+func (parser *fdtParser) errorf(column int, format string, args ...interface{}) {
+	parser.errors = append(parser.errors, &fdtError{line: parser.line, column: column, message: fmt.Sprintf(format, args...)})
+}
+
+// splitFdtTokens split the text at all commas outside of parentheses
+func splitFdtTokens(text string, column int) []fdtToken {
+	tokens := make([]fdtToken, 0)
+	depth := 0
+	start := 0
+	appendToken := func(end int) {
+		t := text[start:end]
+		trimmed := strings.TrimLeft(t, " \t")
+		tokens = append(tokens, fdtToken{text: strings.TrimRight(trimmed, " \t"),
+			column: column + start + len(t) - len(trimmed)})
+	}
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				appendToken(i)
+				start = i + 1
+			}
+		}
+	}
+	appendToken(len(text))
+	return tokens
+}
+
+func (parser *fdtParser) parseLine(line string) {
+	head := line
+	var parents []fdtToken
+	if e := strings.IndexByte(line, '='); e >= 0 {
+		head = line[:e]
+		parents = splitFdtTokens(line[e+1:], e+2)
+	}
+	tokens := splitFdtTokens(head, 1)
+	keyword := strings.ToUpper(tokens[0].text)
+	switch keyword {
+	case "SUPDE", "SUBDE", "HYPDE", "COLDE":
+		if parents == nil {
+			parser.errorf(len(line)+1, "%s definition need parent fields after '='", keyword)
+			return
+		}
+		parser.parseDescriptor(keyword, tokens[1:], parents)
+	case "PHONDE":
+		parser.parsePhonetic(tokens[1:], parents)
+	default:
+		if parents != nil {
+			parser.errorf(len(head)+1, "unexpected '=' in field definition")
+			return
+		}
+		parser.parseField(tokens)
+	}
+}
+
+func (parser *fdtParser) parseNumber(token fdtToken, what string) (int, bool) {
+	n, err := strconv.Atoi(token.text)
+	if err != nil {
+		parser.errorf(token.column, "invalid %s '%s'", what, token.text)
+		return 0, false
+	}
+	return n, true
+}
+
+// isFieldName check if the upper case name is a letter followed by letter or digit
+func isFieldName(name string) bool {
+	return len(name) == 2 && name[0] >= 'A' && name[0] <= 'Z' &&
+		((name[1] >= 'A' && name[1] <= 'Z') || (name[1] >= '0' && name[1] <= '9'))
+}
+
+// checkName check field name rules and uniqueness
+func (parser *fdtParser) checkName(token fdtToken) (string, bool) {
+	name := strings.ToUpper(token.text)
+	if !isFieldName(name) {
+		parser.errorf(token.column, "invalid name '%s', need letter followed by letter or digit", token.text)
+		return name, false
+	}
+	if name[0] == 'E' && name[1] >= '0' && name[1] <= '9' {
+		parser.errorf(token.column, "name '%s' is reserved", name)
+		return name, false
+	}
+	if l, ok := parser.seen[name]; ok {
+		if l == 0 {
+			parser.errorf(token.column, "duplicate name '%s', already defined in the file", name)
+		} else {
+			parser.errorf(token.column, "duplicate name '%s', already defined in line %d", name, l)
+		}
+		return name, false
+	}
+	parser.seen[name] = parser.line
+	return name, true
+}
+
+// parseOptions parse option list, checking for unknown or duplicate entries
+func (parser *fdtParser) parseOptions(tokens []fdtToken, valid []string) ([]string, map[string]int) {
+	options := make([]string, 0)
+	columns := make(map[string]int)
+	for _, t := range tokens {
+		o := strings.ToUpper(t.text)
+		if !containsOption(valid, o) {
+			parser.errorf(t.column, "unknown or invalid option '%s'", t.text)
+			continue
+		}
+		if _, ok := columns[o]; ok {
+			parser.errorf(t.column, "duplicate option '%s'", o)
+			continue
+		}
+		columns[o] = t.column
+		options = append(options, o)
+	}
+	return options, columns
+}
+
+// checkFormat check format and length combination
+func (parser *fdtParser) checkFormat(formatToken fdtToken, length int, lengthColumn int) (string, bool) {
+	format := strings.ToUpper(formatToken.text)
+	max := 0
+	switch format {
+	case "A", "W":
+		max = 253
+	case "B":
+		max = 126
+	case "U":
+		max = 29
+	case "P":
+		max = 15
+	case "F":
+		if length != 1 && length != 2 && length != 4 && length != 8 {
+			parser.errorf(lengthColumn, "length %d invalid for format F, need 1, 2, 4 or 8", length)
+			return format, false
+		}
+		return format, true
+	case "G":
+		if length != 4 && length != 8 {
+			parser.errorf(lengthColumn, "length %d invalid for format G, need 4 or 8", length)
+			return format, false
+		}
+		return format, true
+	default:
+		parser.errorf(formatToken.column, "unknown format '%s'", formatToken.text)
+		return format, false
+	}
+	min := 0
+	if format == "U" || format == "P" {
+		min = 1
+	}
+	if length < min || length > max {
+		parser.errorf(lengthColumn, "length %d invalid for format %s, need %d to %d", length, format, min, max)
+		return format, false
+	}
+	return format, true
+}
+
+var fdtFieldOptions = []string{"DE", "UQ", "NU", "NC", "NN", "MU", "PE", "FI", "LA", "LB", "NB", "NV", "HF", "XI", "MV"}
+
+func (parser *fdtParser) parseField(tokens []fdtToken) {
+	if len(tokens) < 2 {
+		parser.errorf(tokens[0].column, "field definition need level and name")
+		return
+	}
+	level, ok := parser.parseNumber(tokens[0], "level")
+	if !ok {
+		return
+	}
+	if level < 1 || level > 7 {
+		parser.errorf(tokens[0].column, "level %d out of range 1 to 7", level)
+		return
+	}
+	if level > len(parser.groups)+1 {
+		parser.errorf(tokens[0].column, "level %d not allowed here, maximum level is %d", level, len(parser.groups)+1)
+		return
+	}
+	name, _ := parser.checkName(tokens[1])
+	field := &fdtField{level: level, name: name, line: parser.line}
+	rest := tokens[2:]
+	if len(rest) > 0 {
+		if length, err := strconv.Atoi(rest[0].text); err == nil {
+			if len(rest) < 2 {
+				parser.errorf(rest[0].column, "format missing after length")
+				return
+			}
+			field.length = length
+			field.format, _ = parser.checkFormat(rest[1], length, rest[0].column)
+			rest = rest[2:]
+		}
+	}
+	var columns map[string]int
+	field.options, columns = parser.parseOptions(rest, fdtFieldOptions)
+	parser.checkFieldOptions(field, columns)
+
+	parser.groups = parser.groups[:level-1]
+	if level == 1 {
+		parser.definition.fields = append(parser.definition.fields, field)
+	} else {
+		parent := parser.groups[level-2]
+		parent.children = append(parent.children, field)
+	}
+	if field.isGroup() {
+		parser.groups = append(parser.groups, field)
+	}
+	if _, ok := parser.definition.names[name]; !ok {
+		parser.definition.names[name] = field
+	}
+}
+
+// checkFieldOptions check the option combinations of a field
+func (parser *fdtParser) checkFieldOptions(field *fdtField, columns map[string]int) {
+	if field.isGroup() {
+		for _, o := range field.options {
+			if o != "PE" {
+				parser.errorf(columns[o], "option %s not allowed for group %s", o, field.name)
+			}
+		}
+	}
+	if c, ok := columns["PE"]; ok {
+		if field.level != 1 || !field.isGroup() {
+			parser.errorf(c, "option PE only allowed for groups on level 1")
+		}
+	}
+	if c, ok := columns["UQ"]; ok && !field.hasOption("DE") {
+		parser.errorf(c, "option UQ need option DE")
+	}
+	if c, ok := columns["XI"]; ok && !field.hasOption("UQ") {
+		parser.errorf(c, "option XI need option UQ")
+	}
+	if c, ok := columns["NN"]; ok && !field.hasOption("NC") {
+		parser.errorf(c, "option NN need option NC")
+	}
+	if c, ok := columns["NC"]; ok && field.hasOption("NU") {
+		parser.errorf(c, "options NU and NC are mutually exclusive")
+	}
+	if c, ok := columns["LB"]; ok {
+		if field.hasOption("LA") {
+			parser.errorf(c, "options LA and LB are mutually exclusive")
+		}
+		if field.hasOption("DE") {
+			parser.errorf(c, "option LB not allowed for descriptors")
+		}
+	}
+	for _, o := range []string{"LA", "LB"} {
+		if c, ok := columns[o]; ok && !field.isGroup() {
+			if (field.format != "A" && field.format != "W" && field.format != "B") || field.length != 0 {
+				parser.errorf(c, "option %s need variable length format A, B or W", o)
+			}
+		}
+	}
+	if c, ok := columns["FI"]; ok && !field.isGroup() {
+		if field.length == 0 {
+			parser.errorf(c, "option FI not allowed for variable length fields")
+		}
+		for _, o := range []string{"NU", "NC", "MU", "LA", "LB"} {
+			if field.hasOption(o) {
+				parser.errorf(c, "options FI and %s are mutually exclusive", o)
+			}
+		}
+	}
+}
+
+// checkGroups check that all groups contain fields
+func (parser *fdtParser) checkGroups(fields []*fdtField) {
+	for _, f := range fields {
+		if f.isGroup() {
+			if len(f.children) == 0 {
+				parser.errors = append(parser.errors, &fdtError{line: f.line, column: 1,
+					message: fmt.Sprintf("group %s contains no fields", f.name)})
+			}
+			parser.checkGroups(f.children)
+		}
+	}
+}
+
+// parseParents parse parent references like AA or AA(1,4)
+func (parser *fdtParser) parseParents(tokens []fdtToken, withRange bool) []fdtParent {
+	parents := make([]fdtParent, 0)
+	for _, t := range tokens {
+		parent := fdtParent{name: strings.ToUpper(t.text)}
+		if b := strings.IndexByte(t.text, '('); b >= 0 {
+			if !strings.HasSuffix(t.text, ")") {
+				parser.errorf(t.column+len(t.text), "missing ')' in parent '%s'", t.text)
+				continue
+			}
+			parent.name = strings.ToUpper(strings.TrimSpace(t.text[:b]))
+			r := strings.Split(t.text[b+1:len(t.text)-1], ",")
+			var errFrom, errTo error
+			if len(r) == 2 {
+				parent.from, errFrom = strconv.Atoi(strings.TrimSpace(r[0]))
+				parent.to, errTo = strconv.Atoi(strings.TrimSpace(r[1]))
+			}
+			if len(r) != 2 || errFrom != nil || errTo != nil {
+				parser.errorf(t.column+b+1, "invalid byte range in parent '%s', need (from,to)", t.text)
+				continue
+			}
+			if parent.from < 1 || parent.from > parent.to {
+				parser.errorf(t.column+b+1, "invalid byte range %d to %d in parent %s", parent.from, parent.to, parent.name)
+				continue
+			}
+		} else if withRange {
+			parser.errorf(t.column, "parent %s need byte range (from,to)", parent.name)
+			continue
+		}
+		if parent.name == "" {
+			parser.errorf(t.column, "parent field name missing")
+			continue
+		}
+		parents = append(parents, parent)
+	}
+	return parents
+}
+
+var fdtDescriptorOptions = []string{"UQ", "NU", "MU", "PE", "XI"}
+
+func (parser *fdtParser) parseDescriptor(keyword string, tokens []fdtToken, parentTokens []fdtToken) {
+	descriptor := &fdtDescriptor{kind: keyword, line: parser.line}
+	if keyword == "HYPDE" || keyword == "COLDE" {
+		if len(tokens) == 0 {
+			parser.errorf(1, "%s definition need exit number", keyword)
+			return
+		}
+		n, ok := parser.parseNumber(tokens[0], "exit number")
+		if !ok {
+			return
+		}
+		descriptor.number = n
+		tokens = tokens[1:]
+	}
+	if len(tokens) == 0 {
+		parser.errorf(1, "%s definition need descriptor name", keyword)
+		return
+	}
+	descriptor.name, _ = parser.checkName(tokens[0])
+	tokens = tokens[1:]
+	if keyword == "HYPDE" {
+		if len(tokens) < 2 {
+			parser.errorf(1, "HYPDE definition need length and format")
+			return
+		}
+		length, ok := parser.parseNumber(tokens[0], "length")
+		if !ok {
+			return
+		}
+		descriptor.length = length
+		descriptor.format, _ = parser.checkFormat(tokens[1], length, tokens[0].column)
+		tokens = tokens[2:]
+	}
+	var columns map[string]int
+	descriptor.options, columns = parser.parseOptions(tokens, fdtDescriptorOptions)
+	if c, ok := columns["XI"]; ok && !descriptor.hasOption("UQ") {
+		parser.errorf(c, "option XI need option UQ")
+	}
+	descriptor.parents = parser.parseParents(parentTokens, keyword == "SUPDE" || keyword == "SUBDE")
+	switch keyword {
+	case "SUPDE":
+		if len(descriptor.parents) < 2 || len(descriptor.parents) > 20 {
+			parser.errorf(parentTokens[0].column, "super descriptor need 2 to 20 parent fields")
+		}
+	case "SUBDE", "COLDE":
+		if len(descriptor.parents) != 1 {
+			parser.errorf(parentTokens[0].column, "%s definition need exactly one parent field", keyword)
+		}
+	case "HYPDE":
+		if len(descriptor.parents) < 1 || len(descriptor.parents) > 20 {
+			parser.errorf(parentTokens[0].column, "hyper descriptor need 1 to 20 parent fields")
+		}
+	}
+	parser.definition.descriptors = append(parser.definition.descriptors, descriptor)
+}
+
+func (parser *fdtParser) parsePhonetic(tokens []fdtToken, parentTokens []fdtToken) {
+	if parentTokens != nil || len(tokens) != 1 {
+		parser.errorf(1, "phonetic descriptor need definition PHONDE,name(parent)")
+		return
+	}
+	t := tokens[0]
+	b := strings.IndexByte(t.text, '(')
+	if b < 0 || !strings.HasSuffix(t.text, ")") {
+		parser.errorf(t.column, "phonetic descriptor need definition PHONDE,name(parent)")
+		return
+	}
+	descriptor := &fdtDescriptor{kind: "PHONDE", line: parser.line}
+	descriptor.name, _ = parser.checkName(fdtToken{text: strings.TrimSpace(t.text[:b]), column: t.column})
+	descriptor.parents = []fdtParent{{name: strings.ToUpper(strings.TrimSpace(t.text[b+1 : len(t.text)-1]))}}
+	parser.definition.descriptors = append(parser.definition.descriptors, descriptor)
+}
+
+// checkDescriptors check the parent fields of all special descriptors
+func (parser *fdtParser) checkDescriptors() {
+	for _, d := range parser.definition.descriptors {
+		parser.line = d.line
+		for _, p := range d.parents {
+			field := parser.definition.field(p.name)
+			if field == nil {
+				parser.errorf(1, "parent field %s of %s %s not defined", p.name, d.kind, d.name)
+				continue
+			}
+			if field.isGroup() {
+				parser.errorf(1, "parent field %s of %s %s is a group", p.name, d.kind, d.name)
+				continue
+			}
+			if field.hasOption("LB") {
+				parser.errorf(1, "parent field %s of %s %s has option LB", p.name, d.kind, d.name)
+			}
+			if p.to > 0 && field.length > 0 && p.to > field.length {
+				parser.errorf(1, "byte range of parent %s exceed field length %d", p.name, field.length)
+			}
+			switch d.kind {
+			case "PHONDE":
+				if field.format != "A" {
+					parser.errorf(1, "parent field %s of phonetic descriptor %s need format A", p.name, d.name)
+				}
+			case "COLDE":
+				if field.format != "A" && field.format != "W" {
+					parser.errorf(1, "parent field %s of collation descriptor %s need format A or W", p.name, d.name)
+				}
+			}
+		}
+	}
+}
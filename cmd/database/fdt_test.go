@@ -0,0 +1,181 @@
+/*
+* Copyright © 2018 Software AG, Darmstadt, Germany and/or its licensors
+*
+* SPDX-License-Identifier: Apache-2.0
+*
+*   Licensed under the Apache License, Version 2.0 (the "License");
+*   you may not use this file except in compliance with the License.
+*   You may obtain a copy of the License at
+*
+*       http://www.apache.org/licenses/LICENSE-2.0
+*
+*   Unless required by applicable law or agreed to in writing, software
+*   distributed under the License is distributed on an "AS IS" BASIS,
+*   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*   See the License for the specific language governing permissions and
+*   limitations under the License.
+*
+ */
+
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"softwareag.com/models"
+)
+
+const employeesFdt = `; Employees example
+1,AA,8,A,DE,UQ
+1,AB               ; personnel data
+2,AC,20,A,NU
+2,AE,20,A,DE
+1,AG,1,A,FI
+1,AH,4,P,DE,MU,NU
+1,AI,PE
+2,AJ,3,A,NU
+2,AK,0,A,LA
+SUPDE,S1=AA(1,4),AE(1,8)
+SUBDE,S2=AE(1,4)
+PHONDE,S3(AE)
+HYPDE,1,H1,4,P,NU=AH
+COLDE,1,C1,UQ=AE
+`
+
+func TestParseFdt(t *testing.T) {
+	definition, err := parseFdt(employeesFdt)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Len(t, definition.fields, 5)
+	assert.Len(t, definition.descriptors, 5)
+	group := definition.field("AB")
+	assert.True(t, group.isGroup())
+	assert.Len(t, group.children, 2)
+	assert.Equal(t, 2, group.children[1].level)
+	aa := definition.field("AA")
+	assert.Equal(t, 8, aa.length)
+	assert.Equal(t, "A", aa.format)
+	assert.Equal(t, []string{"DE", "UQ"}, aa.options)
+	s1 := definition.descriptor("S1")
+	assert.Equal(t, "SUPDE", s1.kind)
+	assert.Equal(t, []fdtParent{{name: "AA", from: 1, to: 4}, {name: "AE", from: 1, to: 8}}, s1.parents)
+	assert.Equal(t, "AE", definition.descriptor("S3").parents[0].name)
+	assert.Equal(t, 4, definition.descriptor("H1").length)
+}
+
+func TestParseFdtErrors(t *testing.T) {
+	_, err := parseFdt("1,AA,8,A\n1,AA,4,X,UQ\n3,AB,4,A\n")
+	if !assert.Error(t, err) {
+		return
+	}
+	assert.Equal(t, "line 2 column 3: duplicate name 'AA', already defined in line 1\n"+
+		"line 2 column 8: unknown format 'X'\n"+
+		"line 2 column 10: option UQ need option DE\n"+
+		"line 3 column 1: level 3 not allowed here, maximum level is 1", err.Error())
+
+	_, err = parseFdt("1,AA,4,F,NU,NC\n1,AB\n1,E1,2,A\nSUPDE,S1=AA(1,2)\nSUBDE,S2=XX(1,2)")
+	if !assert.Error(t, err) {
+		return
+	}
+	assert.Equal(t, "line 1 column 13: options NU and NC are mutually exclusive\n"+
+		"line 2 column 1: group AB contains no fields\n"+
+		"line 3 column 3: name 'E1' is reserved\n"+
+		"line 4 column 10: super descriptor need 2 to 20 parent fields\n"+
+		"line 5 column 1: parent field XX of SUBDE S2 not defined", err.Error())
+}
+
+func TestFdtSourceParse(t *testing.T) {
+	fdt := &models.FdtFDT{Fields: []*models.Field{
+		{Level: 1, Name: "AA", Length: 8, Format: "A", Flags: "DE,UQ"},
+		{Level: 1, Name: "AB", Length: 4, Format: "P", Flags: "NU"},
+		{Level: 1, Name: "GA"}, {Level: 2, Name: "GB", Length: 2, Format: "A"}},
+		Descriptors: []*models.Field{{Name: "S1", Type: "SUPER",
+			SubFields: []*models.SubField{{SubName: "AA", From: 1, To: 2}, {SubName: "AB", From: 1, To: 4}}}},
+		Referentials: []*models.Field{{Name: "R1", Type: "FOREIGN"}}}
+	source := fdtDefinitionOf(fdt).source()
+	assert.Equal(t, "1,AA,8,A,DE,UQ\n1,AB,4,P,NU\n1,GA\n2,GB,2,A\nSUPDE,S1=AA(1,2),AB(1,4)\n"+
+		"; referential R1 not exported\n", source)
+	definition, err := parseFdt(source)
+	if assert.NoError(t, err) {
+		assert.Len(t, definition.fields, 3)
+		assert.Equal(t, "SUPDE", definition.descriptors[0].kind)
+	}
+}
+
+func TestParseFdtAddition(t *testing.T) {
+	existing := &models.FdtFDT{Fields: []*models.Field{
+		{Type: "FIELD", Level: 1, Name: "AA", Length: 8, Format: "A", Flags: "DE,UQ"}}}
+	_, err := parseFdtAddition("1,AB,4,A\nSUPDE,S1=AA(1,4),AB(1,2)\n", existing)
+	assert.NoError(t, err)
+	_, err = parseFdtAddition("1,AA,4,A\n", existing)
+	if assert.Error(t, err) {
+		assert.Equal(t, "line 1 column 3: duplicate name 'AA', already defined in the file", err.Error())
+	}
+}
+
+func TestDiffFdt(t *testing.T) {
+	current := fdtDefinitionOf(&models.FdtFDT{Fields: []*models.Field{
+		{Type: "FIELD", Level: 1, Name: "AA", Length: 8, Format: "A", Flags: "DE,UQ"},
+		{Type: "FIELD", Level: 1, Name: "AB", Length: 4, Format: "P"},
+		{Type: "FIELD", Level: 1, Name: "AC", Length: 2, Format: "A"}},
+		Descriptors: []*models.Field{{Name: "S1", Type: "SUPER",
+			SubFields: []*models.SubField{{SubName: "AA", From: 1, To: 2}, {SubName: "AB", From: 1, To: 4}}}}})
+	target, err := parseFdt("1,AA,8,A,UQ,DE\n1,AB,6,P\n1,AD,PE\n2,AE,2,A\nSUPDE,S1=AA(1,2),AB(1,4)\n")
+	if !assert.NoError(t, err) {
+		return
+	}
+	changes := diffFdt(current, target)
+	if assert.Len(t, changes, 4) {
+		assert.Equal(t, fdtChangeIncompatible, changes[0].kind)
+		assert.Equal(t, "AB", changes[0].name)
+		assert.Equal(t, fdtChangeAdditive, changes[1].kind)
+		assert.Equal(t, "1,AD,PE", changes[1].source)
+		assert.Equal(t, "2,AE,2,A", changes[2].source)
+		assert.Equal(t, fdtChangeRemovable, changes[3].kind)
+		assert.Equal(t, "AC", changes[3].name)
+	}
+}
+
+func TestDiffFdtReferential(t *testing.T) {
+	current := fdtDefinitionOf(&models.FdtFDT{Fields: []*models.Field{
+		{Type: "FIELD", Level: 1, Name: "AA", Length: 8, Format: "A", Flags: "DE,UQ"}},
+		Referentials: []*models.Field{{Name: "R1", Type: "FOREIGN", Length: 11, Format: "AA"}}})
+	target := fdtDefinitionOf(&models.FdtFDT{Fields: []*models.Field{
+		{Type: "FIELD", Level: 1, Name: "AA", Length: 8, Format: "A", Flags: "DE,UQ"}},
+		Referentials: []*models.Field{{Name: "R1", Type: "FOREIGN", Length: 12, Format: "AA"}}})
+	changes := diffFdt(current, target)
+	if assert.Len(t, changes, 1) {
+		assert.Equal(t, fdtChangeReferential, changes[0].kind)
+		assert.Equal(t, "referential constraint changed", changes[0].description)
+	}
+}
+
+func TestSameOptions(t *testing.T) {
+	assert.True(t, sameOptions([]string{"NU", "DE"}, []string{"DE", "nu"}))
+	assert.False(t, sameOptions([]string{"NU", "DE"}, []string{"DE"}))
+	assert.False(t, sameOptions([]string{"NU", "DE"}, []string{"DE", "UQ"}))
+	a := &fdtDescriptor{kind: "SUPDE", name: "S1", options: []string{"UQ", "NU"}, parents: []fdtParent{{name: "AA"}}}
+	b := &fdtDescriptor{kind: "SUPDE", name: "S1", options: []string{"NU", "UQ"}, parents: []fdtParent{{name: "AA"}}}
+	assert.True(t, sameDescriptor(a, b))
+	b.parents = []fdtParent{{name: "AB"}}
+	assert.False(t, sameDescriptor(a, b))
+	assert.Equal(t, []string{"UQ", "NU"}, a.options)
+}
+
+func TestCheckDropFields(t *testing.T) {
+	fdt := &models.FdtFDT{Fields: []*models.Field{
+		{Type: "FIELD", Level: 1, Name: "AA", Length: 8, Format: "A", Flags: "DE,UQ"},
+		{Type: "FIELD", Level: 1, Name: "AB"},
+		{Type: "FIELD", Level: 2, Name: "AC", Length: 4, Format: "P"},
+		{Type: "FIELD", Level: 1, Name: "AD", Length: 2, Format: "A"}},
+		Descriptors: []*models.Field{{Name: "S1", Type: "SUPER",
+			SubFields: []*models.SubField{{SubName: "AA", From: 1, To: 2}, {SubName: "AC", From: 1, To: 4}}}}}
+	definition := fdtDefinitionOf(fdt)
+	assert.NoError(t, checkDropFields(definition, fdt, []string{"AD"}))
+	assert.NoError(t, checkDropFields(definition, fdt, []string{"S1", "AB"}))
+	assert.EqualError(t, checkDropFields(definition, fdt, []string{"XX"}), "Field XX not defined in the FDT")
+	assert.EqualError(t, checkDropFields(definition, fdt, []string{"AB"}),
+		"Field AC is parent of SUPDE S1, drop the descriptor first")
+}
@@ -0,0 +1,274 @@
+/*
+* Copyright © 2018 Software AG, Darmstadt, Germany and/or its licensors
+*
+* SPDX-License-Identifier: Apache-2.0
+*
+*   Licensed under the Apache License, Version 2.0 (the "License");
+*   you may not use this file except in compliance with the License.
+*   You may obtain a copy of the License at
+*
+*       http://www.apache.org/licenses/LICENSE-2.0
+*
+*   Unless required by applicable law or agreed to in writing, software
+*   distributed under the License is distributed on an "AS IS" BASIS,
+*   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*   See the License for the specific language governing permissions and
+*   limitations under the License.
+*
+ */
+
+package database
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-openapi/runtime"
+	"softwareag.com/client"
+)
+
+// fdtChangeKind classification of a FDT difference
+type fdtChangeKind int
+
+const (
+	fdtChangeAdditive fdtChangeKind = iota
+	fdtChangeRemovable
+	fdtChangeIncompatible
+	fdtChangeReferential
+)
+
+var fdtChangeNames = []string{"additive", "removable", "incompatible", "referential"}
+
+// fdtChange difference between two FDT definitions
+type fdtChange struct {
+	kind        fdtChangeKind
+	name        string
+	description string
+	source      string
+}
+
+// sameOptions compare the options independent of their order, the FDT of the server lists the
+// options in the order of its flags
+func sameOptions(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	as := make([]string, len(a))
+	bs := make([]string, len(b))
+	for i := range a {
+		as[i] = strings.ToUpper(a[i])
+		bs[i] = strings.ToUpper(b[i])
+	}
+	sort.Strings(as)
+	sort.Strings(bs)
+	return strings.Join(as, ",") == strings.Join(bs, ",")
+}
+
+// sameDescriptor compare the descriptor definitions with the options independent of their order
+func sameDescriptor(a, b *fdtDescriptor) bool {
+	if !sameOptions(a.options, b.options) {
+		return false
+	}
+	ac, bc := *a, *b
+	ac.options, bc.options = nil, nil
+	return ac.source() == bc.source()
+}
+
+// diffFdt compare the current FDT definition with the target definition. The changes
+// needed to migrate the current definition into the target definition are returned.
+func diffFdt(current, target *fdtDefinition) []*fdtChange {
+	changes := make([]*fdtChange, 0)
+	for _, f := range target.allFields() {
+		c := current.field(f.name)
+		switch {
+		case c == nil:
+			changes = append(changes, &fdtChange{kind: fdtChangeAdditive, name: f.name,
+				description: "new field", source: f.source()})
+		case c.level != f.level || c.length != f.length || c.format != f.format:
+			changes = append(changes, &fdtChange{kind: fdtChangeIncompatible, name: f.name,
+				description: fmt.Sprintf("field changed from %s to %s", c.source(), f.source())})
+		case !sameOptions(c.options, f.options):
+			changes = append(changes, &fdtChange{kind: fdtChangeIncompatible, name: f.name,
+				description: fmt.Sprintf("options changed from %s to %s", c.source(), f.source())})
+		}
+	}
+	for _, f := range current.allFields() {
+		if target.field(f.name) == nil {
+			changes = append(changes, &fdtChange{kind: fdtChangeRemovable, name: f.name,
+				description: "field removed " + f.source()})
+		}
+	}
+	for _, d := range target.descriptors {
+		c := current.descriptor(d.name)
+		switch {
+		case c == nil:
+			changes = append(changes, &fdtChange{kind: fdtChangeAdditive, name: d.name,
+				description: "new descriptor", source: d.source()})
+		case !sameDescriptor(c, d):
+			changes = append(changes, &fdtChange{kind: fdtChangeIncompatible, name: d.name,
+				description: fmt.Sprintf("descriptor changed from %s to %s", c.source(), d.source())})
+		}
+	}
+	for _, d := range current.descriptors {
+		if target.descriptor(d.name) == nil {
+			changes = append(changes, &fdtChange{kind: fdtChangeRemovable, name: d.name,
+				description: "descriptor removed " + d.source()})
+		}
+	}
+	if current.referentials != nil && target.referentials != nil {
+		for _, n := range sortedKeys(target.referentials) {
+			if c, ok := current.referentials[n]; !ok {
+				changes = append(changes, &fdtChange{kind: fdtChangeReferential, name: n,
+					description: "new referential constraint"})
+			} else if c != target.referentials[n] {
+				changes = append(changes, &fdtChange{kind: fdtChangeReferential, name: n,
+					description: "referential constraint changed"})
+			}
+		}
+		for _, n := range sortedKeys(current.referentials) {
+			if _, ok := target.referentials[n]; !ok {
+				changes = append(changes, &fdtChange{kind: fdtChangeReferential, name: n,
+					description: "referential constraint removed"})
+			}
+		}
+	}
+	return changes
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// DiffFdt compare the FDT of the file with a FDT source file or the FDT of another file and
+// generate the migration. The parameter is fdt:<file> or <dbid>:<fnr> optional on the -url2 server,
+// followed by ',apply' to apply the migration on the file.
+func DiffFdt(clientInstance *client.AdabasAdmin, dbid int, fnr int, targetInstance *client.AdabasAdmin, param string,
+	auth runtime.ClientAuthInfoWriter, targetAuth runtime.ClientAuthInfoWriter) error {
+	if dbid < 1 || fnr < 1 {
+		fmt.Println("Please add option -dbid Adabas database id and -fnr Adabas file number")
+		return fmt.Errorf("Please add option -dbid Adabas database id and -fnr Adabas file number")
+	}
+	p := strings.Split(param, ",")
+	apply := len(p) > 1 && strings.ToLower(strings.TrimSpace(p[1])) == "apply"
+
+	fdt, err := getFdt(clientInstance, dbid, fnr, auth)
+	if err != nil {
+		return err
+	}
+	current := fdtDefinitionOf(fdt)
+	var target *fdtDefinition
+	targetName := strings.TrimSpace(p[0])
+	if strings.HasPrefix(targetName, "fdt:") {
+		raw, rerr := ioutil.ReadFile(targetName[4:])
+		if rerr != nil {
+			fmt.Println(rerr.Error())
+			return rerr
+		}
+		target, err = parseFdt(string(raw))
+		if err != nil {
+			fmt.Println("FDT file " + targetName[4:] + " invalid:")
+			fmt.Println(err.Error())
+			return err
+		}
+	} else {
+		v := strings.Split(targetName, ":")
+		targetDbid, derr := strconv.Atoi(v[0])
+		targetFnr := fnr
+		if derr == nil && len(v) > 1 {
+			targetFnr, derr = strconv.Atoi(v[1])
+		}
+		if derr != nil || len(v) > 2 {
+			fmt.Println("Please add parameter fdt:<file> or <dbid>:<fnr> to compare with, optional with ',apply'")
+			return fmt.Errorf("Please add parameter fdt:<file> or <dbid>:<fnr> to compare with")
+		}
+		targetFdt, terr := getFdt(targetInstance, targetDbid, targetFnr, targetAuth)
+		if terr != nil {
+			return terr
+		}
+		target = fdtDefinitionOf(targetFdt)
+		targetName = fmt.Sprintf("database %03d file %03d", targetDbid, targetFnr)
+	}
+
+	changes := diffFdt(current, target)
+	fmt.Printf("\nFDT differences of database %03d file %03d to %s:\n\n", dbid, fnr, targetName)
+	if len(changes) == 0 {
+		fmt.Println("No differences found")
+		return nil
+	}
+	additions := make([]string, 0)
+	drops := make([]string, 0)
+	incompatible := 0
+	referentials := 0
+	for _, c := range changes {
+		fmt.Printf(" %-12s %s  %s\n", fdtChangeNames[c.kind], c.name, c.description)
+		switch c.kind {
+		case fdtChangeAdditive:
+			additions = append(additions, c.source)
+		case fdtChangeRemovable:
+			drops = append(drops, c.name)
+		case fdtChangeReferential:
+			referentials++
+		default:
+			incompatible++
+		}
+	}
+	fmt.Println()
+	if len(additions) > 0 {
+		fmt.Println("Migration using addfields:")
+		for _, a := range additions {
+			fmt.Println("  " + a)
+		}
+	}
+	if len(drops) > 0 {
+		fmt.Println("Migration using dropfields:")
+		fmt.Println("  " + strings.Join(drops, ","))
+	}
+	if incompatible > 0 {
+		fmt.Printf("%d incompatible changes need unload and reload of the file\n", incompatible)
+	}
+	if referentials > 0 {
+		fmt.Printf("%d referential constraint changes are not migrated, they need to be changed using ADAINV\n", referentials)
+	}
+	if !apply {
+		return nil
+	}
+	if incompatible > 0 {
+		fmt.Println("Migration not applied because of incompatible changes")
+		return fmt.Errorf("Migration not applied because of incompatible changes")
+	}
+	if referentials > 0 {
+		fmt.Println("Migration not applied because of referential constraint changes")
+		return fmt.Errorf("Migration not applied because of referential constraint changes")
+	}
+	if len(drops) > 0 {
+		// Check the drops before anything is changed, like dropfields does
+		err = checkDropFields(current, fdt, drops)
+		if err != nil {
+			fmt.Println(err.Error())
+			fmt.Println("Migration not applied")
+			return err
+		}
+	}
+	fmt.Println()
+	if len(additions) > 0 {
+		err = AddFields(clientInstance, dbid, fnr, strings.Join(additions, "%"), auth)
+		if err != nil {
+			return err
+		}
+	}
+	if len(drops) > 0 {
+		err = dropFields(clientInstance, dbid, fnr, drops, auth)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
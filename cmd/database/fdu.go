@@ -0,0 +1,252 @@
+/*
+* Copyright © 2018 Software AG, Darmstadt, Germany and/or its licensors
+*
+* SPDX-License-Identifier: Apache-2.0
+*
+*   Licensed under the Apache License, Version 2.0 (the "License");
+*   you may not use this file except in compliance with the License.
+*   You may obtain a copy of the License at
+*
+*       http://www.apache.org/licenses/LICENSE-2.0
+*
+*   Unless required by applicable law or agreed to in writing, software
+*   distributed under the License is distributed on an "AS IS" BASIS,
+*   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*   See the License for the specific language governing permissions and
+*   limitations under the License.
+*
+ */
+
+package database
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"softwareag.com/models"
+)
+
+const (
+	// fduUnitDefault FDU size unit as used by the create file templates, the size is given in megabytes
+	fduUnitDefault = int64(0)
+	// fduUnitBlocks FDU size unit for sizes given in blocks, the ADAFDU unit character
+	fduUnitBlocks = int64('B')
+)
+
+// fduAdamKeyDefined FDU ADAM key option set if the file is an ADAM file
+const fduAdamKeyDefined = int64(1)
+
+// fduUnsupported ADAFDU keywords without counterpart in the FDU options of the REST interface
+var fduUnsupported = []string{"userisn", "sysfile", "uq_conflict", "nouserabort"}
+
+const (
+	fduReuseIsn = 1 << iota
+	fduReuseDs
+)
+
+const (
+	fduContiguousDs = 1 << iota
+	fduContiguousNi
+	fduContiguousUi
+)
+
+// fduParser parser state of the native ADAFDU parameter file
+type fduParser struct {
+	fdu    *models.FduFdt
+	errors fdtErrors
+	line   int
+	column int
+	seen   map[string]int
+}
+
+// parseFdu parse the native ADAFDU parameter file into the FDU definition.
+// Sizes without unit are given in megabytes, like in ADAFDU.
+func parseFdu(source string, fdu *models.FduFdt) error {
+	if fdu.FduOptions == nil {
+		fdu.FduOptions = &models.FduFdtFduOptions{}
+	}
+	parser := &fduParser{fdu: fdu, seen: make(map[string]int)}
+	for i, line := range strings.Split(strings.Replace(source, "\r", "", -1), "\n") {
+		parser.line = i + 1
+		if c := strings.IndexByte(line, ';'); c >= 0 {
+			line = line[:c]
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		e := strings.IndexByte(line, '=')
+		if e < 0 {
+			parser.column = len(line) - len(strings.TrimLeft(line, " \t")) + 1
+			parser.errorf("need keyword = value")
+			continue
+		}
+		key := strings.ToLower(strings.TrimSpace(line[:e]))
+		keyColumn := len(line[:e]) - len(strings.TrimLeft(line[:e], " \t")) + 1
+		parser.column = keyColumn
+		if l, ok := parser.seen[key]; ok {
+			parser.errorf("keyword %s already defined in line %d", key, l)
+			continue
+		}
+		parser.seen[key] = parser.line
+		value := strings.TrimSpace(line[e+1:])
+		parser.column = e + 2 + len(line[e+1:]) - len(strings.TrimLeft(line[e+1:], " \t"))
+		if value == "" {
+			parser.errorf("value of keyword %s missing", key)
+			continue
+		}
+		parser.parseKeyword(key, value, keyColumn)
+	}
+	options := fdu.FduOptions
+	if options.FduAdamKey == fduAdamKeyDefined && options.FduDSMUnitDS == fduUnitBlocks {
+		// ADAM calculates the record address out of the DS blocks of the file
+		options.FduAdamDsBlocks = options.FduDSSize
+	}
+	if len(parser.errors) > 0 {
+		return parser.errors
+	}
+	return nil
+}
+
+func (parser *fduParser) errorf(format string, args ...interface{}) {
+	parser.errors = append(parser.errors, &fdtError{line: parser.line, column: parser.column,
+		message: fmt.Sprintf(format, args...)})
+}
+
+func (parser *fduParser) parseKeyword(key, value string, keyColumn int) {
+	options := parser.fdu.FduOptions
+	switch key {
+	case "file":
+		parser.fdu.FileNumber = parser.number(key, value)
+	case "name":
+		options.FduName = value
+	case "maxisn":
+		parser.fdu.MaxIsn = parser.number(key, value)
+	case "dssize":
+		options.FduDSSize, options.FduDSMUnitDS = parser.size(key, value)
+	case "nisize":
+		options.FduNISize, options.FduDSMUnitNI = parser.size(key, value)
+	case "uisize":
+		options.FduUISize, options.FduDSMUnitUI = parser.size(key, value)
+	case "dsrabn":
+		options.FduDSRabn = parser.number(key, value)
+	case "nirabn":
+		options.FduNiRabn = parser.number(key, value)
+	case "uirabn":
+		options.FduUIRabn = parser.number(key, value)
+	case "acrabn":
+		options.FduACrabn = parser.number(key, value)
+	case "assopfac":
+		options.FduAssoPfac = parser.number(key, value)
+	case "datapfac":
+		options.FduDataPfac = parser.number(key, value)
+	case "maxrecl":
+		options.FduMaxRecordLength = parser.number(key, value)
+	case "isnsize":
+		errors := len(parser.errors)
+		options.FduIsnSize = parser.number(key, value)
+		if len(parser.errors) == errors && options.FduIsnSize != 3 && options.FduIsnSize != 4 {
+			parser.errorf("isnsize must be 3 or 4")
+		}
+	case "lobfile":
+		options.FduLobFile = parser.number(key, value)
+	case "syfmax":
+		options.FduSystemFileMaxMu = parser.number(key, value)
+	case "cipher":
+		options.FduCipher = parser.flag(key, value)
+	case "pgm_refresh":
+		options.FduPGMRefresh = parser.flag(key, value)
+	case "reuse":
+		options.FduReuse = parser.list(key, value, map[string]int64{"isn": fduReuseIsn, "ds": fduReuseDs,
+			"noisn": 0, "nods": 0})
+	case "isnreuse":
+		options.FduReuse = parser.reuse(key, value, options.FduReuse, fduReuseIsn)
+	case "dsreuse":
+		options.FduReuse = parser.reuse(key, value, options.FduReuse, fduReuseDs)
+	case "adam_key":
+		options.FduAdamKey, options.FduAdamByteKey = parser.adamKey(key, value)
+	case "adam_offset":
+		options.FduAdamOffset = parser.number(key, value)
+	case "adam_overflow":
+		options.FduOverflowAdam = parser.number(key, value)
+	case "adam_parameter":
+		options.FduParamAdam = parser.number(key, value)
+	case "contiguous":
+		options.FduContiguous = parser.list(key, value, map[string]int64{"ds": fduContiguousDs,
+			"ni": fduContiguousNi, "ui": fduContiguousUi})
+	default:
+		parser.column = keyColumn
+		if containsOption(fduUnsupported, key) {
+			parser.errorf("keyword %s not supported by the REST interface", key)
+			return
+		}
+		parser.errorf("unknown keyword %s", key)
+	}
+}
+
+func (parser *fduParser) number(key, value string) int64 {
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n < 0 {
+		parser.errorf("invalid number '%s' for keyword %s", value, key)
+		return 0
+	}
+	return n
+}
+
+// size parse size in megabytes with optional unit B for blocks, M for megabytes or G for gigabytes
+func (parser *fduParser) size(key, value string) (int64, int64) {
+	switch strings.ToUpper(value[len(value)-1:]) {
+	case "B":
+		return parser.number(key, strings.TrimSpace(value[:len(value)-1])), fduUnitBlocks
+	case "M":
+		value = value[:len(value)-1]
+	case "G":
+		return parser.number(key, strings.TrimSpace(value[:len(value)-1])) * 1024, fduUnitDefault
+	}
+	return parser.number(key, strings.TrimSpace(value)), fduUnitDefault
+}
+
+// adamKey parse the ADAM key, either ISN or the name of the unique descriptor
+func (parser *fduParser) adamKey(key, value string) (int64, string) {
+	name := strings.ToUpper(value)
+	if name != "ISN" && !isFieldName(name) {
+		parser.errorf("invalid value '%s' for keyword %s, need ISN or field name", value, key)
+		return 0, ""
+	}
+	return fduAdamKeyDefined, name
+}
+
+func (parser *fduParser) flag(key, value string) int64 {
+	switch strings.ToLower(value) {
+	case "yes", "on", "1":
+		return 1
+	case "no", "off", "0":
+		return 0
+	}
+	parser.errorf("invalid value '%s' for keyword %s, need yes or no", value, key)
+	return 0
+}
+
+// reuse set or clear the reuse bit with yes or no
+func (parser *fduParser) reuse(key, value string, mask, bit int64) int64 {
+	if parser.flag(key, value) == 1 {
+		return mask | bit
+	}
+	return mask &^ bit
+}
+
+// list parse list values like (isn,ds) into a bit mask
+func (parser *fduParser) list(key, value string, valid map[string]int64) int64 {
+	value = strings.TrimSuffix(strings.TrimPrefix(value, "("), ")")
+	mask := int64(0)
+	for _, v := range strings.Split(value, ",") {
+		v = strings.ToLower(strings.TrimSpace(v))
+		b, ok := valid[v]
+		if !ok {
+			parser.errorf("invalid value '%s' for keyword %s", v, key)
+			continue
+		}
+		mask |= b
+	}
+	return mask
+}
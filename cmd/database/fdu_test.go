@@ -0,0 +1,123 @@
+/*
+* Copyright © 2018 Software AG, Darmstadt, Germany and/or its licensors
+*
+* SPDX-License-Identifier: Apache-2.0
+*
+*   Licensed under the Apache License, Version 2.0 (the "License");
+*   you may not use this file except in compliance with the License.
+*   You may obtain a copy of the License at
+*
+*       http://www.apache.org/licenses/LICENSE-2.0
+*
+*   Unless required by applicable law or agreed to in writing, software
+*   distributed under the License is distributed on an "AS IS" BASIS,
+*   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*   See the License for the specific language governing permissions and
+*   limitations under the License.
+*
+ */
+
+package database
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"softwareag.com/models"
+)
+
+func TestParseFdu(t *testing.T) {
+	fdu := &models.FduFdt{FileNumber: 11}
+	err := parseFdu("; employees\nname   = employees\ndssize = 1m\nnisize = 2M\nuisize = 3\n"+
+		"maxisn = 2000\nreuse  = (isn,ds)\nsyfmax=9\n", fdu)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, int64(11), fdu.FileNumber)
+	assert.Equal(t, int64(2000), fdu.MaxIsn)
+	assert.Equal(t, "employees", fdu.FduOptions.FduName)
+	assert.Equal(t, int64(1), fdu.FduOptions.FduDSSize)
+	assert.Equal(t, fduUnitDefault, fdu.FduOptions.FduDSMUnitDS)
+	assert.Equal(t, int64(2), fdu.FduOptions.FduNISize)
+	assert.Equal(t, int64(3), fdu.FduOptions.FduUISize)
+	assert.Equal(t, int64(fduReuseIsn|fduReuseDs), fdu.FduOptions.FduReuse)
+	assert.Equal(t, int64(9), fdu.FduOptions.FduSystemFileMaxMu)
+}
+
+func TestParseFduTemplate(t *testing.T) {
+	raw, err := ioutil.ReadFile("../../templates/emp.fdu")
+	if !assert.NoError(t, err) {
+		return
+	}
+	fdu := &models.FduFdt{}
+	err = parseFdu(string(raw), fdu)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "employees", fdu.FduOptions.FduName)
+	assert.Equal(t, int64(1), fdu.FduOptions.FduDSSize)
+	assert.Equal(t, fduUnitDefault, fdu.FduOptions.FduDSMUnitDS)
+	assert.Equal(t, int64(90), fdu.FduOptions.FduNISize)
+	assert.Equal(t, fduUnitBlocks, fdu.FduOptions.FduDSMUnitNI)
+	assert.Equal(t, int64(15), fdu.FduOptions.FduUISize)
+	assert.Equal(t, fduUnitBlocks, fdu.FduOptions.FduDSMUnitUI)
+	assert.Equal(t, int64(2000), fdu.MaxIsn)
+}
+
+func TestParseFduSizes(t *testing.T) {
+	fdu := &models.FduFdt{}
+	err := parseFdu("dssize = 2g\nnisize = 3M\nuisize = 10 b\n", fdu)
+	if assert.NoError(t, err) {
+		assert.Equal(t, int64(2048), fdu.FduOptions.FduDSSize)
+		assert.Equal(t, fduUnitDefault, fdu.FduOptions.FduDSMUnitDS)
+		assert.Equal(t, int64(3), fdu.FduOptions.FduNISize)
+		assert.Equal(t, int64(10), fdu.FduOptions.FduUISize)
+		assert.Equal(t, fduUnitBlocks, fdu.FduOptions.FduDSMUnitUI)
+	}
+}
+
+func TestParseFduAdam(t *testing.T) {
+	fdu := &models.FduFdt{}
+	err := parseFdu("dssize = 100b\nadam_key = aa\nadam_offset = 2\nadam_overflow = 10\nadam_parameter = 3\n", fdu)
+	if assert.NoError(t, err) {
+		assert.Equal(t, fduAdamKeyDefined, fdu.FduOptions.FduAdamKey)
+		assert.Equal(t, "AA", fdu.FduOptions.FduAdamByteKey)
+		assert.Equal(t, int64(2), fdu.FduOptions.FduAdamOffset)
+		assert.Equal(t, int64(10), fdu.FduOptions.FduOverflowAdam)
+		assert.Equal(t, int64(3), fdu.FduOptions.FduParamAdam)
+		assert.Equal(t, int64(100), fdu.FduOptions.FduAdamDsBlocks)
+	}
+}
+
+func TestParseFduReuse(t *testing.T) {
+	fdu := &models.FduFdt{}
+	err := parseFdu("reuse = (isn,ds)\ndsreuse = no\n", fdu)
+	if assert.NoError(t, err) {
+		assert.Equal(t, int64(fduReuseIsn), fdu.FduOptions.FduReuse)
+	}
+	fdu = &models.FduFdt{}
+	err = parseFdu("isnreuse = yes\ndsreuse = yes\n", fdu)
+	if assert.NoError(t, err) {
+		assert.Equal(t, int64(fduReuseIsn|fduReuseDs), fdu.FduOptions.FduReuse)
+	}
+}
+
+func TestParseFduErrors(t *testing.T) {
+	err := parseFdu("name = x\nsize = 1\nreuse = (isn,xx)\nmaxisn = abc\nname = y\n", &models.FduFdt{})
+	if assert.Error(t, err) {
+		assert.Equal(t, "line 2 column 1: unknown keyword size\n"+
+			"line 3 column 9: invalid value 'xx' for keyword reuse\n"+
+			"line 4 column 10: invalid number 'abc' for keyword maxisn\n"+
+			"line 5 column 1: keyword name already defined in line 1", err.Error())
+	}
+	err = parseFdu("isnsize = x\nuserisn = yes\nnisize = xb\nadam_key = 1a\n", &models.FduFdt{})
+	if assert.Error(t, err) {
+		assert.Equal(t, "line 1 column 11: invalid number 'x' for keyword isnsize\n"+
+			"line 2 column 1: keyword userisn not supported by the REST interface\n"+
+			"line 3 column 10: invalid number 'x' for keyword nisize\n"+
+			"line 4 column 12: invalid value '1a' for keyword adam_key, need ISN or field name", err.Error())
+	}
+	err = parseFdu("isnsize = 5\n", &models.FduFdt{})
+	assert.EqualError(t, err, "line 1 column 11: isnsize must be 3 or 4")
+}
@@ -22,6 +22,8 @@ package database
 import (
 	"bytes"
 	"fmt"
+	"io/ioutil"
+	"strings"
 
 	"github.com/go-openapi/runtime"
 	"golang.org/x/text/language"
@@ -31,8 +33,8 @@ import (
 	"softwareag.com/models"
 )
 
-// Fields list fields of a Adabas file
-func Fields(clientInstance *client.AdabasAdmin, dbid int, fnr int, auth runtime.ClientAuthInfoWriter) error {
+// getFdt read the field definition table of the Adabas file
+func getFdt(clientInstance *client.AdabasAdmin, dbid int, fnr int, auth runtime.ClientAuthInfoWriter) (*models.FdtFDT, error) {
 	params := online_offline.NewGetFieldDefinitionTableParams()
 	rfc3339 := true
 	params.Rfc3339 = &rfc3339
@@ -47,19 +49,56 @@ func Fields(clientInstance *client.AdabasAdmin, dbid int, fnr int, auth runtime.
 		default:
 			fmt.Println("Error:", err)
 		}
+		return nil, err
+	}
+	return resp.Payload.FDT, nil
+}
+
+// Fields list fields of a Adabas file, the parameter tree shows the field hierarchy and
+// the parameter source or source=<file> generate the FDT source
+func Fields(clientInstance *client.AdabasAdmin, dbid int, fnr int, param string, auth runtime.ClientAuthInfoWriter) error {
+	fdt, err := getFdt(clientInstance, dbid, fnr, auth)
+	if err != nil {
 		return err
 	}
 
 	p := message.NewPrinter(language.English)
 
+	switch {
+	case param == "":
+	case strings.ToLower(param) == "tree":
+		printFieldTree(p, dbid, fnr, fdt)
+		return nil
+	case strings.ToLower(param) == "source":
+		p.Print(fdtDefinitionOf(fdt).source())
+		return nil
+	case strings.HasPrefix(strings.ToLower(param), "source="):
+		fileName := param[len("source="):]
+		source := fmt.Sprintf("; FDT of database %03d file %03d\n", dbid, fnr) + fdtDefinitionOf(fdt).source()
+		if _, perr := parseFdt(source); perr != nil {
+			fmt.Println("Warning, generated FDT source not valid:")
+			fmt.Println(perr.Error())
+		}
+		err = ioutil.WriteFile(fileName, []byte(source), 0644)
+		if err != nil {
+			fmt.Println("Error writing FDT:", err)
+			return err
+		}
+		p.Printf("FDT of database %03d file %03d written to %s\n", dbid, fnr, fileName)
+		return nil
+	default:
+		fmt.Println("Parameter need to be tree, source or source=<file>")
+		return fmt.Errorf("Parameter need to be tree, source or source=<file>")
+	}
+
 	p.Printf("\nDatabase %03d file %03d field definition table:\n", dbid, fnr)
 	p.Println()
-	p.Printf("Fields : %d\n", len(resp.Payload.FDT.Fields))
+	p.Printf("Fields : %d\n", len(fdt.Fields))
 	p.Printf("Field Definition Table:\n")
 	p.Println()
 	p.Printf("   Level  I Name I Length I Format I   Options         I Flags   I Encoding\n")
 	p.Printf("-------------------------------------------------------------------------------\n")
-	for _, f := range resp.Payload.FDT.Fields {
+	for _, f := range fdt.Fields {
 		printFields(p, f)
 	}
 	p.Println()
@@ -67,17 +106,17 @@ func Fields(clientInstance *client.AdabasAdmin, dbid int, fnr int, auth runtime.
 	p.Println("-------------------------------------------------------------------------------")
 	p.Println("   Type   I Name I Length I Format I   Options         I Parent field(s)   Fmt")
 	p.Println("-------------------------------------------------------------------------------")
-	for _, f := range resp.Payload.FDT.Descriptors {
+	for _, f := range fdt.Descriptors {
 		printFields(p, f)
 	}
-	if len(resp.Payload.FDT.Referentials) > 0 {
+	if len(fdt.Referentials) > 0 {
 		p.Println()
 		p.Println("Referential Integrity")
 		p.Println("-------------------------------------------------------------------------------")
 		p.Println("	Type   I Name I Refer. I PrimaryI Foreign I Rules")
 		p.Println("	       I      I file   I  field I  field  I")
 		p.Println("-------------------------------------------------------------------------------")
-		for _, f := range resp.Payload.FDT.Referentials {
+		for _, f := range fdt.Referentials {
 			printFields(p, f)
 		}
 	}
@@ -86,6 +125,24 @@ func Fields(clientInstance *client.AdabasAdmin, dbid int, fnr int, auth runtime.
 
 // AddFields add Adabas fields
 func AddFields(clientInstance *client.AdabasAdmin, dbid int, fnr int, fdt string, auth runtime.ClientAuthInfoWriter) error {
+	if strings.HasPrefix(fdt, "fdt:") {
+		existing, err := getFdt(clientInstance, dbid, fnr, auth)
+		if err != nil {
+			return err
+		}
+		raw, err := ioutil.ReadFile(fdt[4:])
+		if err != nil {
+			fmt.Println(err.Error())
+			return err
+		}
+		_, err = parseFdtAddition(string(raw), existing)
+		if err != nil {
+			fmt.Println("FDT file " + fdt[4:] + " invalid:")
+			fmt.Println(err.Error())
+			return err
+		}
+		fdt = joinFdt(raw)
+	}
 	params := online_offline.NewModifyFieldDefinitionTableParams()
 	params.Dbid = float64(dbid)
 	params.File = float64(fnr)
@@ -108,6 +165,120 @@ func AddFields(clientInstance *client.AdabasAdmin, dbid int, fnr int, fdt string
 
 }
 
+// DropFields drop Adabas fields given as comma separated list of field names
+func DropFields(clientInstance *client.AdabasAdmin, dbid int, fnr int, param string, auth runtime.ClientAuthInfoWriter) error {
+	if dbid < 1 || fnr < 1 {
+		fmt.Println("Please add option -dbid Adabas database id and -fnr Adabas file number")
+		return fmt.Errorf("Please add option -dbid Adabas database id and -fnr Adabas file number")
+	}
+	names := make([]string, 0)
+	for _, n := range strings.Split(param, ",") {
+		n = strings.ToUpper(strings.TrimSpace(n))
+		if n != "" {
+			names = append(names, n)
+		}
+	}
+	if len(names) == 0 {
+		fmt.Println("Please add parameter with the list of fields to be dropped")
+		return fmt.Errorf("Please add parameter with the list of fields to be dropped")
+	}
+	fdt, err := getFdt(clientInstance, dbid, fnr, auth)
+	if err != nil {
+		return err
+	}
+	definition := fdtDefinitionOf(fdt)
+	err = checkDropFields(definition, fdt, names)
+	if err != nil {
+		fmt.Println(err.Error())
+		return err
+	}
+
+	fmt.Printf("\nDrop in database %03d file %03d:\n\n", dbid, fnr)
+	for _, n := range names {
+		if f := definition.field(n); f != nil {
+			fmt.Println("  field      " + f.source())
+			for _, c := range f.children {
+				fmt.Println("    sub field  " + c.source())
+			}
+			if f.hasOption("DE") {
+				fmt.Println("    descriptor, the inverted list is removed")
+			}
+			continue
+		}
+		fmt.Println("  descriptor " + definition.descriptor(n).source())
+	}
+	fmt.Println()
+	return dropFields(clientInstance, dbid, fnr, names, auth)
+}
+
+// checkDropFields check that the fields exist and are not referenced by super or sub descriptors
+// or referential constraints which are not dropped together with the field
+func checkDropFields(definition *fdtDefinition, fdt *models.FdtFDT, names []string) error {
+	dropped := make(map[string]bool)
+	var drop func(f *fdtField)
+	drop = func(f *fdtField) {
+		dropped[f.name] = true
+		for _, c := range f.children {
+			drop(c)
+		}
+	}
+	for _, n := range names {
+		if f := definition.field(n); f != nil {
+			drop(f)
+		} else if definition.descriptor(n) != nil {
+			dropped[n] = true
+		} else {
+			return fmt.Errorf("Field %s not defined in the FDT", n)
+		}
+	}
+	for _, d := range definition.descriptors {
+		if dropped[d.name] {
+			continue
+		}
+		for _, p := range d.parents {
+			if dropped[p.name] {
+				switch d.kind {
+				case "SUPDE", "SUBDE":
+					return fmt.Errorf("Field %s is parent of %s %s, drop the descriptor first", p.name, d.kind, d.name)
+				default:
+					fmt.Printf("Warning, field %s is parent of %s %s\n", p.name, d.kind, d.name)
+				}
+			}
+		}
+	}
+	for _, r := range fdt.Referentials {
+		for _, s := range r.SubFields {
+			if dropped[s.SubName] {
+				return fmt.Errorf("Field %s is used by referential constraint %s", s.SubName, r.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// dropFields drop the Adabas fields
+func dropFields(clientInstance *client.AdabasAdmin, dbid int, fnr int, fields []string, auth runtime.ClientAuthInfoWriter) error {
+	params := online_offline.NewDropFieldsParams()
+	params.Dbid = float64(dbid)
+	params.File = float64(fnr)
+	params.Fields = strings.Join(fields, ",")
+	fmt.Println("Drop fields", params.Fields)
+	resp, err := clientInstance.OnlineOffline.DropFields(params, auth)
+	if err != nil {
+		switch err.(type) {
+		case *online_offline.DropFieldsBadRequest:
+			response := err.(*online_offline.DropFieldsBadRequest)
+			fmt.Println(response.Payload.Error.Code, ":", response.Payload.Error.Message)
+		default:
+			fmt.Println("Error:", err)
+		}
+		return err
+	}
+
+	fmt.Println("Status: ", resp.Payload.Status.Message)
+	return nil
+}
+
 func printFields(p *message.Printer, f *models.Field) {
 	space := bytes.Buffer{}
 	for i := 0; i < int(f.Level); i++ {
@@ -147,3 +318,81 @@ func printFields(p *message.Printer, f *models.Field) {
 	}
 
 }
+
+// printFieldTree print the fields as tree with the derived descriptors
+func printFieldTree(p *message.Printer, dbid int, fnr int, fdt *models.FdtFDT) {
+	derived := make(map[string][]string)
+	for _, d := range fdt.Descriptors {
+		for _, s := range d.SubFields {
+			derived[s.SubName] = append(derived[s.SubName], fmt.Sprintf("%s %s", strings.ToLower(d.Type), d.Name))
+		}
+	}
+
+	p.Printf("\nDatabase %03d file %03d field tree:\n\n", dbid, fnr)
+	for i, f := range fdt.Fields {
+		indent := strings.Repeat("  ", int(f.Level))
+		options := fieldOptions(f.Flags)
+		attributes := make([]string, 0)
+		if f.Format == "" {
+			group := i+1 < len(fdt.Fields) && fdt.Fields[i+1].Level > f.Level
+			switch {
+			case containsOption(options, "PE"):
+				attributes = append(attributes, "periodic group")
+			case group:
+				attributes = append(attributes, "group")
+			}
+		} else {
+			attributes = append(attributes, fmt.Sprintf("%d,%s", f.Length, f.Format))
+		}
+		if containsOption(options, "MU") {
+			attributes = append(attributes, "multiple value")
+		}
+		if containsOption(options, "LB") {
+			attributes = append(attributes, "LOB")
+		}
+		for _, o := range options {
+			switch o {
+			case "PE", "MU", "LB":
+			default:
+				attributes = append(attributes, o)
+			}
+		}
+		p.Printf("%s%d %s  %s\n", indent, f.Level, f.Name, strings.Join(attributes, " "))
+		for _, d := range derived[f.Name] {
+			p.Printf("%s     -> %s\n", indent, d)
+		}
+	}
+	if len(fdt.Descriptors) > 0 {
+		p.Printf("\nDerived descriptors:\n\n")
+		for _, d := range fdt.Descriptors {
+			parents := make([]string, 0)
+			for _, s := range d.SubFields {
+				if s.From == 0 && s.To == 0 {
+					parents = append(parents, s.SubName)
+				} else {
+					parents = append(parents, fmt.Sprintf("%s(%d,%d)", s.SubName, s.From, s.To))
+				}
+			}
+			p.Printf("  %-10s %s  %s = %s\n", d.Type, d.Name, strings.Join(fieldOptions(d.Flags), ","),
+				strings.Join(parents, " + "))
+		}
+	}
+	for _, r := range fdt.Referentials {
+		p.Printf("\nReferential %s\n", r.Name)
+	}
+}
+
+// fieldOptions extract the FDT options out of the flags of a field
+func fieldOptions(flags string) []string {
+	options := make([]string, 0)
+	for _, o := range strings.FieldsFunc(flags, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '|'
+	}) {
+		o = strings.ToUpper(o)
+		switch o {
+		case "DE", "UQ", "NU", "NC", "NN", "MU", "PE", "FI", "LA", "LB", "NB", "NV", "HF", "XI", "MV":
+			options = append(options, o)
+		}
+	}
+	return options
+}
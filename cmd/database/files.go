@@ -300,14 +300,26 @@ func RefreshFile(clientInstance *client.AdabasAdmin, dbid int, fnr int, auth run
 	return nil
 }
 
-func loadFdt(fdt string) string {
+// loadFdt load and validate the FDT source file, the lines are joined with % as expected by the server
+func loadFdt(fdt string) (string, error) {
 	fmt.Println("Loading FDT file at " + fdt)
-	raw, err := os.Open(fdt[4:])
+	raw, err := ioutil.ReadFile(fdt[4:])
 	if err != nil {
 		fmt.Println(err.Error())
-		os.Exit(1)
+		return "", err
 	}
-	scanner := bufio.NewScanner(raw)
+	_, err = parseFdt(string(raw))
+	if err != nil {
+		fmt.Println("FDT file " + fdt[4:] + " invalid:")
+		fmt.Println(err.Error())
+		return "", err
+	}
+	return joinFdt(raw), nil
+}
+
+// joinFdt remove comments and white spaces out of the FDT source and join the lines with %
+func joinFdt(raw []byte) string {
+	scanner := bufio.NewScanner(bytes.NewReader(raw))
 	var buffer bytes.Buffer
 	r := regexp.MustCompile(" *;.*")
 	for scanner.Scan() {
@@ -342,7 +354,11 @@ func createFileInstance(dbid int, fnr int, input InputList) *models.FduFdt {
 	fdu.FduOptions = &models.FduFdtFduOptions{}
 	for _, il := range input {
 		if strings.HasPrefix(il, "fdt:") {
-			loadedFdt = loadFdt(il)
+			var err error
+			loadedFdt, err = loadFdt(il)
+			if err != nil {
+				return nil
+			}
 		} else if strings.HasPrefix(il, "fdu:") {
 			fileName := il[4:]
 			fmt.Println("Loading FDU file at " + fileName)
@@ -352,8 +368,14 @@ func createFileInstance(dbid int, fnr int, input InputList) *models.FduFdt {
 				os.Exit(1)
 			}
 
-			if err := json.Unmarshal(raw, fdu); err != nil {
-				log.Fatal(err)
+			if strings.HasPrefix(strings.TrimSpace(string(raw)), "{") {
+				if err := json.Unmarshal(raw, fdu); err != nil {
+					log.Fatal(err)
+				}
+			} else if err := parseFdu(string(raw), fdu); err != nil {
+				fmt.Println("FDU file " + fileName + " invalid:")
+				fmt.Println(err.Error())
+				return nil
 			}
 		} else {
 			log.Fatal("Prefix fdt: or fdu: missing")
@@ -396,6 +418,34 @@ func CreateFile(clientInstance *client.AdabasAdmin, dbid int, fnr int, input Inp
 	return nil
 }
 
+// CheckFdt validate the FDT source files given by input without sending them to the server
+func CheckFdt(input InputList) error {
+	if len(input) == 0 {
+		fmt.Println("Please add -input parameter for FDT")
+		return fmt.Errorf("Please add -input parameter for FDT")
+	}
+	for _, il := range input {
+		if !strings.HasPrefix(il, "fdt:") {
+			fmt.Println("Prefix fdt: missing")
+			return fmt.Errorf("Prefix fdt: missing")
+		}
+		raw, err := ioutil.ReadFile(il[4:])
+		if err != nil {
+			fmt.Println(err.Error())
+			return err
+		}
+		definition, err := parseFdt(string(raw))
+		if err != nil {
+			fmt.Println("FDT file " + il[4:] + " invalid:")
+			fmt.Println(err.Error())
+			return err
+		}
+		fmt.Printf("FDT file %s valid: %d fields, %d special descriptors\n", il[4:], len(definition.names),
+			len(definition.descriptors))
+	}
+	return nil
+}
+
 // DeleteFile delete database file
 func DeleteFile(clientInstance *client.AdabasAdmin, dbid int, fnr int, auth runtime.ClientAuthInfoWriter) error {
 	params := online_offline.NewDeleteFileParams()
@@ -0,0 +1,133 @@
+/*
+* Copyright © 2018 Software AG, Darmstadt, Germany and/or its licensors
+*
+* SPDX-License-Identifier: Apache-2.0
+*
+*   Licensed under the Apache License, Version 2.0 (the "License");
+*   you may not use this file except in compliance with the License.
+*   You may obtain a copy of the License at
+*
+*       http://www.apache.org/licenses/LICENSE-2.0
+*
+*   Unless required by applicable law or agreed to in writing, software
+*   distributed under the License is distributed on an "AS IS" BASIS,
+*   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*   See the License for the specific language governing permissions and
+*   limitations under the License.
+*
+ */
+
+package database
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	httptransport "github.com/go-openapi/runtime/client"
+	"softwareag.com/client"
+)
+
+// historyEnvironment environment variable defining the local history directory
+const historyEnvironment = "ADABAS_ADMIN_HISTORY"
+
+// historyDirectory directory of the local history, given by ADABAS_ADMIN_HISTORY or
+// .adabas-admin in the home directory
+func historyDirectory() (string, error) {
+	if d := os.Getenv(historyEnvironment); d != "" {
+		return d, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".adabas-admin"), nil
+}
+
+// historyServer host and port of the RESTful server, the history of equal database ids on
+// different servers is kept apart
+func historyServer(clientInstance *client.AdabasAdmin) string {
+	if clientInstance == nil {
+		return ""
+	}
+	if transport, ok := clientInstance.Transport.(*httptransport.Runtime); ok {
+		return transport.Host
+	}
+	return ""
+}
+
+// historyFile file name of the history of the given type and server, one JSON entry per line
+func historyFile(name string, server string, dbid int) (string, error) {
+	directory, err := historyDirectory()
+	if err != nil {
+		return "", err
+	}
+	if server == "" {
+		return filepath.Join(directory, fmt.Sprintf("%s-%03d.json", name, dbid)), nil
+	}
+	server = strings.NewReplacer(":", "_", "/", "_", "\\", "_").Replace(server)
+	return filepath.Join(directory, fmt.Sprintf("%s-%s-%03d.json", name, server, dbid)), nil
+}
+
+// appendHistory append the entry to the local history
+func appendHistory(name string, server string, dbid int, entry interface{}) error {
+	fileName, err := historyFile(name, server, dbid)
+	if err != nil {
+		fmt.Println("Error locating history:", err)
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(fileName), 0700)
+	if err != nil {
+		fmt.Println("Error creating history directory:", err)
+		return err
+	}
+	raw, err := json.Marshal(entry)
+	if err != nil {
+		fmt.Println("Error generating JSON:", err)
+		return err
+	}
+	f, err := os.OpenFile(fileName, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		fmt.Println("Error opening history:", err)
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(raw, '\n'))
+	if err != nil {
+		fmt.Println("Error writing history:", err)
+		return err
+	}
+	return nil
+}
+
+// readHistory read all entries of the local history, a missing history returns no entries
+func readHistory(name string, server string, dbid int) ([]json.RawMessage, error) {
+	entries := make([]json.RawMessage, 0)
+	fileName, err := historyFile(name, server, dbid)
+	if err != nil {
+		fmt.Println("Error locating history:", err)
+		return nil, err
+	}
+	raw, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return entries, nil
+		}
+		fmt.Println("Error reading history:", err)
+		return nil, err
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(raw))
+	scanner.Buffer(make([]byte, 64*1024), len(raw)+1)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) > 0 {
+			entries = append(entries, json.RawMessage(append([]byte(nil), line...)))
+		}
+	}
+	return entries, scanner.Err()
+}
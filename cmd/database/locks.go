@@ -0,0 +1,256 @@
+/*
+* Copyright © 2018 Software AG, Darmstadt, Germany and/or its licensors
+*
+* SPDX-License-Identifier: Apache-2.0
+*
+*   Licensed under the Apache License, Version 2.0 (the "License");
+*   you may not use this file except in compliance with the License.
+*   You may obtain a copy of the License at
+*
+*       http://www.apache.org/licenses/LICENSE-2.0
+*
+*   Unless required by applicable law or agreed to in writing, software
+*   distributed under the License is distributed on an "AS IS" BASIS,
+*   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*   See the License for the specific language governing permissions and
+*   limitations under the License.
+*
+ */
+
+package database
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-openapi/runtime"
+	"softwareag.com/client"
+	"softwareag.com/models"
+)
+
+// hotIsnLimit number of ISNs shown per file in the hot ISN list
+const hotIsnLimit = 5
+
+// lockKey record identified by file and ISN
+type lockKey struct {
+	file int64
+	isn  int64
+}
+
+// lockRecord hold queue entry with all holders and the users waiting for the record
+type lockRecord struct {
+	key     lockKey
+	locks   string
+	flags   string
+	holders []string
+	waiters []string
+}
+
+// lockAnalysis records in the hold queue and the wait-for relation between users
+type lockAnalysis struct {
+	records []*lockRecord
+	waitFor map[string][]string
+}
+
+// userKey key identifying an Adabas user in the user, hold and command queue
+func userKey(u *models.UserInformation) string {
+	if u == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%s/%s/%d", strings.TrimSpace(u.Node), strings.TrimSpace(u.Terminal), u.ID)
+}
+
+func containsKey(list []string, key string) bool {
+	for _, k := range list {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// analyzeLocks join the hold queue with the command queue. Commands on a held record of users not
+// holding the record are waiting for the holders.
+func analyzeLocks(holdQueue []*models.HoldQueueEntry, commands []*models.CommandQueueEntry) *lockAnalysis {
+	analysis := &lockAnalysis{waitFor: make(map[string][]string)}
+	records := make(map[lockKey]*lockRecord)
+	for _, h := range holdQueue {
+		key := lockKey{file: h.File, isn: h.Isn}
+		r, ok := records[key]
+		if !ok {
+			r = &lockRecord{key: key, locks: h.Locks, flags: h.Flags}
+			records[key] = r
+			analysis.records = append(analysis.records, r)
+		}
+		for _, u := range h.Hid {
+			if k := userKey(u); !containsKey(r.holders, k) {
+				r.holders = append(r.holders, k)
+			}
+		}
+	}
+	for _, c := range commands {
+		r, ok := records[lockKey{file: c.File, isn: c.Isn}]
+		if !ok {
+			continue
+		}
+		k := userKey(c.User)
+		if containsKey(r.holders, k) || containsKey(r.waiters, k) {
+			continue
+		}
+		r.waiters = append(r.waiters, k)
+		for _, h := range r.holders {
+			if !containsKey(analysis.waitFor[k], h) {
+				analysis.waitFor[k] = append(analysis.waitFor[k], h)
+			}
+		}
+	}
+	sort.Slice(analysis.records, func(i, j int) bool {
+		if analysis.records[i].key.file != analysis.records[j].key.file {
+			return analysis.records[i].key.file < analysis.records[j].key.file
+		}
+		return analysis.records[i].key.isn < analysis.records[j].key.isn
+	})
+	return analysis
+}
+
+// deadlocks find cycles in the wait-for relation using a depth-first search visiting each user once.
+// A wait on a user still on the search path closes a cycle, each cycle is returned starting with
+// its smallest user key.
+func (analysis *lockAnalysis) deadlocks() [][]string {
+	const (
+		unvisited = iota
+		onPath
+		done
+	)
+	cycles := make([][]string, 0)
+	state := make(map[string]int)
+	waiters := make([]string, 0, len(analysis.waitFor))
+	for w := range analysis.waitFor {
+		waiters = append(waiters, w)
+	}
+	sort.Strings(waiters)
+	var path []string
+	var visit func(u string)
+	visit = func(u string) {
+		state[u] = onPath
+		path = append(path, u)
+		for _, h := range analysis.waitFor[u] {
+			switch state[h] {
+			case unvisited:
+				visit(h)
+			case onPath:
+				i := len(path) - 1
+				for path[i] != h {
+					i--
+				}
+				cycle := append([]string(nil), path[i:]...)
+				first := 0
+				for j := range cycle {
+					if cycle[j] < cycle[first] {
+						first = j
+					}
+				}
+				cycles = append(cycles, append(cycle[first:], cycle[:first]...))
+			}
+		}
+		path = path[:len(path)-1]
+		state[u] = done
+	}
+	for _, w := range waiters {
+		if state[w] == unvisited {
+			visit(w)
+		}
+	}
+	return cycles
+}
+
+// hotIsns records per file sorted by the number of holders and waiters
+func (analysis *lockAnalysis) hotIsns() map[int64][]*lockRecord {
+	hot := make(map[int64][]*lockRecord)
+	for _, r := range analysis.records {
+		hot[r.key.file] = append(hot[r.key.file], r)
+	}
+	for _, list := range hot {
+		sort.SliceStable(list, func(i, j int) bool {
+			return len(list[i].holders)+len(list[i].waiters) > len(list[j].holders)+len(list[j].waiters)
+		})
+	}
+	return hot
+}
+
+// Locks analyze the lock contention, showing the holders and waiters of each record in the hold queue,
+// the hot ISNs per file and potential deadlock cycles
+func Locks(clientInstance *client.AdabasAdmin, dbid int, auth runtime.ClientAuthInfoWriter) error {
+	if dbid < 1 {
+		fmt.Println("Please add option -dbid Adabas database id")
+		return fmt.Errorf("Please add option -dbid Adabas database id")
+	}
+	holdQueue, err := getHoldQueue(clientInstance, dbid, auth)
+	if err != nil {
+		return err
+	}
+	commands, err := getCommandQueue(clientInstance, dbid, auth)
+	if err != nil {
+		return err
+	}
+	users, err := getUserQueue(clientInstance, dbid, auth)
+	if err != nil {
+		return err
+	}
+	names := make(map[string]string)
+	for _, u := range users {
+		names[userKey(u.UID)] = fmt.Sprintf("%d:%s", u.UqID, strings.TrimSpace(u.User))
+	}
+	label := func(keys []string) string {
+		list := make([]string, 0, len(keys))
+		for _, k := range keys {
+			if n, ok := names[k]; ok {
+				list = append(list, n)
+			} else {
+				list = append(list, k)
+			}
+		}
+		return strings.Join(list, ",")
+	}
+	analysis := analyzeLocks(holdQueue, commands)
+
+	fmt.Printf("\nLock analysis of database %03d:\n\n", dbid)
+	if len(analysis.records) == 0 {
+		fmt.Println(" No records in hold")
+		return nil
+	}
+	fmt.Printf(" %5s %12s %-6s %-6s %-30s %s\n", "File", "ISN", "Locks", "Flags", "Holder", "Waiting")
+	for _, r := range analysis.records {
+		fmt.Printf(" %5d %12d %-6s %-6s %-30s %s\n", r.key.file, r.key.isn, r.locks, r.flags, label(r.holders), label(r.waiters))
+	}
+
+	fmt.Printf("\nHot ISNs per file:\n\n")
+	hot := analysis.hotIsns()
+	files := make([]int64, 0, len(hot))
+	for f := range hot {
+		files = append(files, f)
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i] < files[j] })
+	for _, f := range files {
+		list := make([]string, 0)
+		for i, r := range hot[f] {
+			if i == hotIsnLimit {
+				break
+			}
+			list = append(list, fmt.Sprintf("%d(%d holder, %d waiting)", r.key.isn, len(r.holders), len(r.waiters)))
+		}
+		fmt.Printf(" File %3d: %d records in hold, %s\n", f, len(hot[f]), strings.Join(list, " "))
+	}
+
+	cycles := analysis.deadlocks()
+	fmt.Println()
+	if len(cycles) == 0 {
+		fmt.Println("No potential deadlock found")
+	}
+	for _, c := range cycles {
+		fmt.Printf("Potential deadlock: %s -> %s\n", strings.Replace(label(c), ",", " -> ", -1), label(c[:1]))
+	}
+	fmt.Println()
+	return nil
+}
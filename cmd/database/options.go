@@ -0,0 +1,53 @@
+/*
+* Copyright © 2018 Software AG, Darmstadt, Germany and/or its licensors
+*
+* SPDX-License-Identifier: Apache-2.0
+*
+*   Licensed under the Apache License, Version 2.0 (the "License");
+*   you may not use this file except in compliance with the License.
+*   You may obtain a copy of the License at
+*
+*       http://www.apache.org/licenses/LICENSE-2.0
+*
+*   Unless required by applicable law or agreed to in writing, software
+*   distributed under the License is distributed on an "AS IS" BASIS,
+*   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*   See the License for the specific language governing permissions and
+*   limitations under the License.
+*
+ */
+
+package database
+
+import (
+	"fmt"
+	"strings"
+)
+
+// parseKeyValues parse command parameter of the type key1=value1,key2=value2 into a map with
+// lower case keys. Only the keys given in valid are accepted.
+func parseKeyValues(param string, valid ...string) (map[string]string, error) {
+	values := make(map[string]string)
+	for _, p := range strings.Split(param, ",") {
+		if strings.TrimSpace(p) == "" {
+			continue
+		}
+		v := strings.SplitN(p, "=", 2)
+		key := strings.ToLower(strings.TrimSpace(v[0]))
+		if len(v) != 2 {
+			return nil, fmt.Errorf("Parameter %s not valid, need of the type: key1=value1,key2=value2", p)
+		}
+		found := false
+		for _, k := range valid {
+			if k == key {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("Parameter key %s unknown, valid keys are: %s", key, strings.Join(valid, ","))
+		}
+		values[key] = strings.TrimSpace(v[1])
+	}
+	return values, nil
+}
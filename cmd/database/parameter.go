@@ -0,0 +1,463 @@
+/*
+* Copyright © 2018 Software AG, Darmstadt, Germany and/or its licensors
+*
+* SPDX-License-Identifier: Apache-2.0
+*
+*   Licensed under the Apache License, Version 2.0 (the "License");
+*   you may not use this file except in compliance with the License.
+*   You may obtain a copy of the License at
+*
+*       http://www.apache.org/licenses/LICENSE-2.0
+*
+*   Unless required by applicable law or agreed to in writing, software
+*   distributed under the License is distributed on an "AS IS" BASIS,
+*   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*   See the License for the specific language governing permissions and
+*   limitations under the License.
+*
+ */
+
+package database
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/go-openapi/runtime"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"softwareag.com/client"
+	"softwareag.com/client/online_offline"
+	"softwareag.com/models"
+)
+
+// parameterKind value type of a parameter
+type parameterKind int
+
+const (
+	parameterInteger parameterKind = iota
+	parameterBoolean
+	parameterString
+	parameterAR
+	parameterOptions
+	parameterLogging
+	parameterUserexits
+)
+
+// parameterDefinition typed definition of a parameter set with PutAdabasParameter
+type parameterDefinition struct {
+	name        string
+	kind        parameterKind
+	intField    func(params *online_offline.PutAdabasParameterParams) **int64
+	boolField   func(params *online_offline.PutAdabasParameterParams) **bool
+	stringField func(params *online_offline.PutAdabasParameterParams) **string
+}
+
+func intParameter(name string, field func(params *online_offline.PutAdabasParameterParams) **int64) *parameterDefinition {
+	return &parameterDefinition{name: name, kind: parameterInteger, intField: field}
+}
+
+func boolParameter(name string, field func(params *online_offline.PutAdabasParameterParams) **bool) *parameterDefinition {
+	return &parameterDefinition{name: name, kind: parameterBoolean, boolField: field}
+}
+
+func stringParameter(name string, kind parameterKind, field func(params *online_offline.PutAdabasParameterParams) **string) *parameterDefinition {
+	return &parameterDefinition{name: name, kind: kind, stringField: field}
+}
+
+type putParams = online_offline.PutAdabasParameterParams
+
+// parameterCatalogue all parameters which can be set
+var parameterCatalogue = []*parameterDefinition{
+	stringParameter("AR", parameterAR, func(p *putParams) **string { return &p.AR }),
+	boolParameter("BI", func(p *putParams) **bool { return &p.BI }),
+	intParameter("CLOGLAYOUT", func(p *putParams) **int64 { return &p.CLOGLAYOUT }),
+	boolParameter("PLOG", func(p *putParams) **bool { return &p.PLOG }),
+	intParameter("LPXA", func(p *putParams) **int64 { return &p.LPXA }),
+	intParameter("BFIO", func(p *putParams) **int64 { return &p.BFIO }),
+	intParameter("CLOGBMAX", func(p *putParams) **int64 { return &p.CLOGBMAX }),
+	stringParameter("LOGGING", parameterLogging, func(p *putParams) **string { return &p.LOGGING }),
+	intParameter("NCL", func(p *putParams) **int64 { return &p.NCL }),
+	intParameter("NISNHQ", func(p *putParams) **int64 { return &p.NISNHQ }),
+	intParameter("NT", func(p *putParams) **int64 { return &p.NT }),
+	intParameter("NU", func(p *putParams) **int64 { return &p.NU }),
+	stringParameter("OPTIONS", parameterOptions, func(p *putParams) **string { return &p.OPTIONS }),
+	intParameter("TNAA", func(p *putParams) **int64 { return &p.TNAA }),
+	intParameter("TNAE", func(p *putParams) **int64 { return &p.TNAE }),
+	intParameter("TNAX", func(p *putParams) **int64 { return &p.TNAX }),
+	intParameter("TT", func(p *putParams) **int64 { return &p.TT }),
+	stringParameter("USEREXITS", parameterUserexits, func(p *putParams) **string { return &p.USEREXITS }),
+	intParameter("RPL_RECORDS", func(p *putParams) **int64 { return &p.RPLRECORDS }),
+	intParameter("RPL_BLOCKS", func(p *putParams) **int64 { return &p.RPLBLOCKS }),
+	intParameter("RPL_TOTAL", func(p *putParams) **int64 { return &p.RPLTOTAL }),
+	intParameter("LAB", func(p *putParams) **int64 { return &p.LAB }),
+	intParameter("LABX", func(p *putParams) **int64 { return &p.LABX }),
+	intParameter("LBP", func(p *putParams) **int64 { return &p.LBP }),
+	intParameter("LWP", func(p *putParams) **int64 { return &p.LWP }),
+	intParameter("WRITE_LIMIT", func(p *putParams) **int64 { return &p.WRITELIMIT }),
+	intParameter("APU_UNITS", func(p *putParams) **int64 { return &p.APUUNITS }),
+	intParameter("APU_WORKERS", func(p *putParams) **int64 { return &p.APUWORKERS }),
+	intParameter("APU_RECVS", func(p *putParams) **int64 { return &p.APURECVS }),
+	boolParameter("ADATCP", func(p *putParams) **bool { return &p.ADATCP }),
+	intParameter("ADATCPPORT", func(p *putParams) **int64 { return &p.ADATCPPORT }),
+	intParameter("ADATCPATB", func(p *putParams) **int64 { return &p.ADATCPATB }),
+	intParameter("ADATCPRECEIVER", func(p *putParams) **int64 { return &p.ADATCPRECEIVER }),
+	intParameter("ADATCPCONNECTIONS", func(p *putParams) **int64 { return &p.ADATCPCONNECTIONS }),
+	intParameter("SSL_PORT", func(p *putParams) **int64 { return &p.SSLPORT }),
+	stringParameter("SSL_CERTFILE", parameterString, func(p *putParams) **string { return &p.SSLCERTFILE }),
+	stringParameter("SSL_KEYFILE", parameterString, func(p *putParams) **string { return &p.SSLKEYFILE }),
+	intParameter("SSL_VERIFY", func(p *putParams) **int64 { return &p.SSLVERIFY }),
+	stringParameter("SSL_CAFILE", parameterString, func(p *putParams) **string { return &p.SSLCAFILE }),
+	stringParameter("SSL_CADIRECTORY", parameterString, func(p *putParams) **string { return &p.SSLCADIRECTORY }),
+	stringParameter("SSL_PASSWORD", parameterString, func(p *putParams) **string { return &p.SSLPASSWORD }),
+}
+
+var optionsNames = []string{"TRUNCATION", "UTILITIES_ONLY", "LOCAL_UTILITIES", "OPEN_REQUIRED", "FAULT_TOLERANT_AR",
+	"AUTORESTART_ONLY", "READ_ONLY", "XA", "AUTO_EXPAND", "DEACTIVATE"}
+
+var loggingNames = []string{"CB", "FB", "IB", "IO", "RB", "SB", "VB", "OFF", "BD", "ENABLED", "AR"}
+
+var userexitNames = []string{"1", "2", "4", "11", "14"}
+
+// parameterKey key of the parameter name ignoring case and underscores
+func parameterKey(name string) string {
+	return strings.Replace(strings.ToUpper(strings.TrimSpace(name)), "_", "", -1)
+}
+
+// findParameter search the parameter definition of the given name
+func findParameter(name string) *parameterDefinition {
+	key := parameterKey(name)
+	for _, d := range parameterCatalogue {
+		if parameterKey(d.name) == key {
+			return d
+		}
+	}
+	return nil
+}
+
+// symbolicList validate a symbolic list like (AUTO_EXPAND,TRUNCATION) or (1,4). Bit masks of the
+// server are decoded with normalizeParameter before, input values are never taken as bit mask.
+func symbolicList(name string, value string, valid []string) (string, error) {
+	value = strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(value), "("), ")")
+	list := make([]string, 0)
+	for _, v := range strings.Split(value, ",") {
+		v = strings.ToUpper(strings.TrimSpace(v))
+		if v == "" {
+			continue
+		}
+		if !containsOption(valid, v) {
+			return "", fmt.Errorf("Value %s of parameter %s invalid, valid values are: %s", v, name, strings.Join(valid, ","))
+		}
+		list = append(list, v)
+	}
+	return strings.Join(list, ","), nil
+}
+
+// apply validate the value and set it in the parameter request, the information of the
+// parameter is used to check the range and the dynamic setting
+func (d *parameterDefinition) apply(params *online_offline.PutAdabasParameterParams, value string,
+	info *models.ParameterInfo) (string, error) {
+	if info != nil && params.Type == "dynamic" && !info.IsDynamic {
+		return "", fmt.Errorf("Parameter %s cannot be changed dynamically", d.name)
+	}
+	switch d.kind {
+	case parameterInteger:
+		i, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("Value %s of parameter %s is not a number", value, d.name)
+		}
+		if info != nil && info.IsMinValueAvailable && i < info.MinValue {
+			return "", fmt.Errorf("Value %d of parameter %s below minimum %d", i, d.name, info.MinValue)
+		}
+		if info != nil && info.IsMaxValueAvailable != nil && *info.IsMaxValueAvailable && i > info.MaxValue {
+			return "", fmt.Errorf("Value %d of parameter %s above maximum %d", i, d.name, info.MaxValue)
+		}
+		*d.intField(params) = &i
+		return strconv.FormatInt(i, 10), nil
+	case parameterBoolean:
+		var b bool
+		switch strings.ToLower(value) {
+		case "on", "yes", "true", "1":
+			b = true
+		case "off", "no", "false", "0":
+			b = false
+		default:
+			return "", fmt.Errorf("Value %s of parameter %s need to be ON or OFF", value, d.name)
+		}
+		*d.boolField(params) = &b
+		return strconv.FormatBool(b), nil
+	case parameterAR:
+		v := strings.ToUpper(value)
+		if v != "CONTINUE" && v != "ABORT" {
+			return "", fmt.Errorf("Value %s of parameter %s need to be CONTINUE or ABORT", value, d.name)
+		}
+		*d.stringField(params) = &v
+		return v, nil
+	case parameterOptions, parameterLogging, parameterUserexits:
+		var v string
+		var err error
+		switch d.kind {
+		case parameterOptions:
+			v, err = symbolicList(d.name, value, optionsNames)
+		case parameterLogging:
+			v, err = symbolicList(d.name, value, loggingNames)
+		default:
+			v, err = symbolicList(d.name, value, userexitNames)
+		}
+		if err != nil {
+			return "", err
+		}
+		send := v
+		if send == "" {
+			send = " "
+		}
+		*d.stringField(params) = &send
+		return v, nil
+	}
+	*d.stringField(params) = &value
+	return value, nil
+}
+
+// getParameterInfo read the parameter information, mapped by the parameter key
+func getParameterInfo(clientInstance *client.AdabasAdmin, dbid int, auth runtime.ClientAuthInfoWriter) (map[string]*models.ParameterInfo, error) {
+	params := online_offline.NewGetDatabaseParameterInfoParams()
+	params.Dbid = float64(dbid)
+	resp, err := clientInstance.OnlineOffline.GetDatabaseParameterInfo(params, auth)
+	if err != nil {
+		switch err.(type) {
+		case *online_offline.GetDatabaseParameterInfoBadRequest:
+			response := err.(*online_offline.GetDatabaseParameterInfoBadRequest)
+			fmt.Println(response.Payload.Error.Code, ":", response.Payload.Error.Message)
+		default:
+			fmt.Println("Error:", err)
+		}
+		return nil, err
+	}
+	infos := make(map[string]*models.ParameterInfo)
+	for _, i := range resp.Payload.ParameterInfo.Parameter {
+		if i.Acronym != "" {
+			infos[parameterKey(i.Acronym)] = i
+		}
+	}
+	return infos, nil
+}
+
+// SetParameter set parameter given as list like NT=20,OPTIONS=(AUTO_EXPAND),type=dynamic
+func SetParameter(clientInstance *client.AdabasAdmin, dbid int, param string, auth runtime.ClientAuthInfoWriter) error {
+	params := online_offline.NewPutAdabasParameterParams()
+	params.Dbid = float64(dbid)
+	params.Type = "static"
+	values := make([]parameterSet, 0)
+	for _, t := range splitFdtTokens(param, 1) {
+		v := strings.SplitN(t.text, "=", 2)
+		if len(v) != 2 {
+			fmt.Printf("Parameter %s not valid, need of the type: param1=1,param2=ON,param3=(A,B)\n", param)
+			return fmt.Errorf("Parameter %s not valid, need of the type: param1=1,param2=ON,param3=(A,B)", param)
+		}
+		if strings.ToLower(strings.TrimSpace(v[0])) == "type" {
+			switch t := strings.ToLower(strings.TrimSpace(v[1])); t {
+			case "static", "dynamic":
+				params.Type = t
+			default:
+				fmt.Println("Please add parameter type=static or type=dynamic")
+				return fmt.Errorf("Please add parameter type=static or type=dynamic")
+			}
+			continue
+		}
+		values = append(values, parameterSet{name: strings.TrimSpace(v[0]), value: strings.TrimSpace(v[1])})
+	}
+	if len(values) == 0 {
+		fmt.Println("Please add parameter list to be set, like NT=20,type=dynamic")
+		return fmt.Errorf("Please add parameter list to be set")
+	}
+	infos, err := getParameterInfo(clientInstance, dbid, auth)
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0)
+	invalid := 0
+	for _, v := range values {
+		d := findParameter(v.name)
+		if d == nil {
+			fmt.Printf("Parameter %s unknown\n", v.name)
+			invalid++
+			continue
+		}
+		if _, verr := d.apply(params, v.value, infos[parameterKey(d.name)]); verr != nil {
+			fmt.Println(verr.Error())
+			invalid++
+			continue
+		}
+		names = append(names, d.name)
+	}
+	if invalid > 0 {
+		return fmt.Errorf("%d invalid parameter values", invalid)
+	}
+	err = snapshotParameter(clientInstance, dbid, "setparameter "+param, auth)
+	if err != nil {
+		return err
+	}
+	return putParameter(clientInstance, dbid, params, names, auth)
+}
+
+// putParameter send the parameter request and show the previous and new values of the parameters
+func putParameter(clientInstance *client.AdabasAdmin, dbid int, params *online_offline.PutAdabasParameterParams,
+	names []string, auth runtime.ClientAuthInfoWriter) error {
+	before, err := getParameter(clientInstance, dbid, params.Type, auth)
+	if err != nil {
+		return err
+	}
+	resp, err := clientInstance.OnlineOffline.PutAdabasParameter(params, auth)
+	if err != nil {
+		switch err.(type) {
+		case *online_offline.PutAdabasParameterBadRequest:
+			response := err.(*online_offline.PutAdabasParameterBadRequest)
+			fmt.Println(response.Payload.Error.Code, ":", response.Payload.Error.Message)
+		default:
+			fmt.Println("Error:", err)
+		}
+		return err
+	}
+	fmt.Println()
+	fmt.Printf(" Adabas parameter: %s\n", resp.Payload.Status.Message)
+	after, err := getParameter(clientInstance, dbid, params.Type, auth)
+	if err != nil {
+		return err
+	}
+	printParameterChanges(before, after, names)
+	return nil
+}
+
+// printParameterChanges print the values of the parameters before and after the change
+func printParameterChanges(before, after *models.ParameterParameter, names []string) {
+	beforeList := parameterList(before)
+	afterList := parameterList(after)
+	fmt.Println()
+	for i, b := range beforeList {
+		selected := names == nil
+		for _, n := range names {
+			if parameterKey(n) == parameterKey(b.name) {
+				selected = true
+			}
+		}
+		if !selected {
+			continue
+		}
+		bv := normalizeParameter(b.name, b.value)
+		av := normalizeParameter(afterList[i].name, afterList[i].value)
+		if bv == av {
+			fmt.Printf(" %-20s %s (unchanged)\n", b.name, bv)
+		} else {
+			fmt.Printf(" %-20s %s -> %s\n", b.name, bv, av)
+		}
+	}
+}
+
+// getParameter read the static or dynamic parameter of the database
+func getParameter(clientInstance *client.AdabasAdmin, dbid int, parameterType string, auth runtime.ClientAuthInfoWriter) (*models.ParameterParameter, error) {
+	params := online_offline.NewGetDatabaseParameterParams()
+	params.Dbid = float64(dbid)
+	params.Type = parameterType
+	resp, err := clientInstance.OnlineOffline.GetDatabaseParameter(params, auth)
+	if err != nil {
+		switch err.(type) {
+		case *online_offline.GetDatabaseParameterBadRequest:
+			response := err.(*online_offline.GetDatabaseParameterBadRequest)
+			fmt.Println(response.Payload.Error.Code, ":", response.Payload.Error.Message)
+		default:
+			fmt.Println("Error:", err)
+		}
+		return nil, err
+	}
+	return resp.Payload.Parameter, nil
+}
+
+// parameterList list all parameter name and value pairs using the JSON names
+func parameterList(dbParameter *models.ParameterParameter) []parameterSet {
+	list := make([]parameterSet, 0)
+	val := reflect.ValueOf(*dbParameter)
+	typ := reflect.TypeOf(*dbParameter)
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		n, ok := field.Tag.Lookup("json")
+		if !ok {
+			n = field.Name
+		} else {
+			ec := strings.IndexByte(n, ',')
+			if ec > 0 {
+				n = n[:ec]
+			}
+		}
+		list = append(list, parameterSet{name: n, value: fmt.Sprintf("%v", val.Field(i))})
+	}
+	return list
+}
+
+// normalizeParameter decode bit mask parameter values into symbolic names
+func normalizeParameter(name, value string) string {
+	if _, err := strconv.Atoi(value); err != nil {
+		return value
+	}
+	switch strings.ToUpper(name) {
+	case "OPTIONS":
+		return checkOptions(value)
+	case "LOGGING":
+		return checkLogging(value)
+	case "USEREXITS":
+		return checkUserexits(value)
+	}
+	return value
+}
+
+// DiffParameter compare the parameter of two databases
+func DiffParameter(clientInstance *client.AdabasAdmin, dbid int, targetInstance *client.AdabasAdmin, param string,
+	auth runtime.ClientAuthInfoWriter, targetAuth runtime.ClientAuthInfoWriter) error {
+	if dbid < 1 {
+		fmt.Println("Please add option -dbid Adabas database id")
+		return fmt.Errorf("Please add option -dbid Adabas database id")
+	}
+	p := strings.Split(param, ",")
+	targetDbid, err := strconv.Atoi(strings.TrimSpace(p[0]))
+	if err != nil || targetDbid < 1 {
+		fmt.Println("Please add parameter with the database id to compare with, optional with ',all' to show all parameter")
+		return fmt.Errorf("Please add parameter with the database id to compare with")
+	}
+	showAll := len(p) > 1 && strings.ToLower(strings.TrimSpace(p[1])) == "all"
+
+	pr := message.NewPrinter(language.English)
+	differences := 0
+	for _, t := range []string{"static", "dynamic"} {
+		source, err := getParameter(clientInstance, dbid, t, auth)
+		if err != nil {
+			return err
+		}
+		target, err := getParameter(targetInstance, targetDbid, t, targetAuth)
+		if err != nil {
+			return err
+		}
+		sourceList := parameterList(source)
+		targetList := parameterList(target)
+
+		pr.Println()
+		pr.Printf(" Adabas %s parameter:\n", t)
+		pr.Printf(" %-20s %-30s %-30s\n", "Parameter", fmt.Sprintf("Database %03d", dbid), fmt.Sprintf("Database %03d", targetDbid))
+		pr.Printf(" %-20s %-30s %-30s\n", "---------", "------------", "------------")
+		for i, s := range sourceList {
+			sv := normalizeParameter(s.name, s.value)
+			tv := normalizeParameter(targetList[i].name, targetList[i].value)
+			mark := " "
+			if sv != tv {
+				mark = "*"
+				differences++
+			} else if !showAll {
+				continue
+			}
+			pr.Printf("%s%-20s %-30s %-30s\n", mark, s.name, sv, tv)
+		}
+	}
+	pr.Println()
+	pr.Printf(" %d parameter differences found\n", differences)
+	return nil
+}
@@ -0,0 +1,312 @@
+/*
+* Copyright © 2018 Software AG, Darmstadt, Germany and/or its licensors
+*
+* SPDX-License-Identifier: Apache-2.0
+*
+*   Licensed under the Apache License, Version 2.0 (the "License");
+*   you may not use this file except in compliance with the License.
+*   You may obtain a copy of the License at
+*
+*       http://www.apache.org/licenses/LICENSE-2.0
+*
+*   Unless required by applicable law or agreed to in writing, software
+*   distributed under the License is distributed on an "AS IS" BASIS,
+*   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*   See the License for the specific language governing permissions and
+*   limitations under the License.
+*
+ */
+
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-openapi/runtime"
+	"github.com/go-openapi/swag"
+	"softwareag.com/client"
+	"softwareag.com/client/online_offline"
+	"softwareag.com/models"
+)
+
+// parameterApplyReason reason of the parameter snapshot taken before applyparameter, followed by the
+// parameter type and the parameter file
+const parameterApplyReason = "applyparameter"
+
+// parameterRollbackReason reason of the parameter snapshot taken before the rollback of applyparameter
+const parameterRollbackReason = "applyparameter rollback"
+
+// readParameterFile read a YAML or JSON parameter file, like the parameter-static.json
+// written by export. The parameters may be enclosed in a Parameter object.
+func readParameterFile(fileName string) ([]parameterSet, error) {
+	raw, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := swag.BytesToYAMLDoc(raw)
+	if err != nil {
+		return nil, fmt.Errorf("Parameter file %s invalid: %v", fileName, err)
+	}
+	js, err := swag.YAMLToJSON(doc)
+	if err != nil {
+		return nil, fmt.Errorf("Parameter file %s invalid: %v", fileName, err)
+	}
+	values := make(map[string]interface{})
+	err = json.Unmarshal(js, &values)
+	if err != nil {
+		return nil, fmt.Errorf("Parameter file %s need to contain a parameter object: %v", fileName, err)
+	}
+	if p, ok := values["Parameter"].(map[string]interface{}); ok && len(values) == 1 {
+		values = p
+	}
+	names := make([]string, 0, len(values))
+	for n := range values {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	list := make([]parameterSet, 0, len(names))
+	for _, n := range names {
+		list = append(list, parameterSet{name: n, value: parameterFileValue(values[n])})
+	}
+	return list, nil
+}
+
+// parameterFileValue string representation of a parameter file value, lists are joined by comma
+func parameterFileValue(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	case []interface{}:
+		list := make([]string, 0, len(t))
+		for _, e := range t {
+			list = append(list, parameterFileValue(e))
+		}
+		return strings.Join(list, ",")
+	}
+	return strings.TrimSpace(fmt.Sprintf("%v", v))
+}
+
+// parameterBody validate the parameter values and generate the parameter object sent with
+// postAdabasParameter. Integer values are sent as numbers, all other values as strings.
+func parameterBody(values []parameterSet, parameterType string, infos map[string]*models.ParameterInfo) (map[string]interface{}, []string, error) {
+	params := online_offline.NewPutAdabasParameterParams()
+	params.Type = parameterType
+	body := make(map[string]interface{})
+	names := make([]string, 0)
+	invalid := 0
+	for _, v := range values {
+		d := findParameter(v.name)
+		if d == nil {
+			fmt.Printf("Parameter %s unknown\n", v.name)
+			invalid++
+			continue
+		}
+		value, err := d.apply(params, v.value, infos[parameterKey(d.name)])
+		if err != nil {
+			fmt.Println(err.Error())
+			invalid++
+			continue
+		}
+		switch d.kind {
+		case parameterInteger:
+			body[d.name] = *(*d.intField(params))
+		case parameterBoolean:
+			if *(*d.boolField(params)) {
+				body[d.name] = "ON"
+			} else {
+				body[d.name] = "OFF"
+			}
+		default:
+			body[d.name] = value
+		}
+		names = append(names, d.name)
+	}
+	if invalid > 0 {
+		return nil, nil, fmt.Errorf("%d invalid parameter values", invalid)
+	}
+	return body, names, nil
+}
+
+// serverParameterSets values of the named parameters as read from the server. The bit masks of the
+// server are decoded once into the lists accepted as input.
+func serverParameterSets(parameters *models.ParameterParameter, names []string) []parameterSet {
+	values := make([]parameterSet, 0)
+	for _, p := range parameterList(parameters) {
+		for _, n := range names {
+			if parameterKey(n) == parameterKey(p.name) {
+				values = append(values, parameterSet{name: n, value: normalizeParameter(p.name, p.value)})
+			}
+		}
+	}
+	return values
+}
+
+// sameParameterValue check if the new value is equal to the current server value of the parameter
+func sameParameterValue(d *parameterDefinition, current, value string) bool {
+	params := online_offline.NewPutAdabasParameterParams()
+	c, cerr := d.apply(params, normalizeParameter(d.name, current), nil)
+	v, verr := d.apply(params, value, nil)
+	if cerr != nil || verr != nil {
+		return false
+	}
+	cl := strings.Split(c, ",")
+	vl := strings.Split(v, ",")
+	sort.Strings(cl)
+	sort.Strings(vl)
+	return strings.Join(cl, ",") == strings.Join(vl, ",")
+}
+
+// postParameter send the parameter object with postAdabasParameter
+func postParameter(clientInstance *client.AdabasAdmin, dbid int, parameterType string, body map[string]interface{},
+	auth runtime.ClientAuthInfoWriter) error {
+	params := online_offline.NewPostAdabasParameterParams()
+	params.Dbid = float64(dbid)
+	params.Type = parameterType
+	params.Parameter = body
+	resp, err := clientInstance.OnlineOffline.PostAdabasParameter(params, auth)
+	if err != nil {
+		switch err.(type) {
+		case *online_offline.PostAdabasParameterBadRequest:
+			response := err.(*online_offline.PostAdabasParameterBadRequest)
+			fmt.Println(response.Payload.Error.Code, ":", response.Payload.Error.Message)
+		default:
+			fmt.Println("Error:", err)
+		}
+		return err
+	}
+	fmt.Println()
+	fmt.Printf(" Adabas parameter: %s\n", resp.Payload.Status.Message)
+	return nil
+}
+
+// ApplyParameter apply all parameters of a YAML or JSON parameter file in one request. The parameter
+// defines type=static or type=dynamic, rollback=true restores the values before the last change.
+func ApplyParameter(clientInstance *client.AdabasAdmin, dbid int, input string, param string, auth runtime.ClientAuthInfoWriter) error {
+	if dbid < 1 {
+		fmt.Println("Please add option -dbid Adabas database id")
+		return fmt.Errorf("Please add option -dbid Adabas database id")
+	}
+	options, err := parseKeyValues(param, "type", "rollback")
+	if err != nil {
+		fmt.Println(err.Error())
+		return err
+	}
+	if b, ok := options["rollback"]; ok {
+		rollback, berr := strconv.ParseBool(b)
+		if berr != nil {
+			fmt.Println("Parameter rollback need true or false")
+			return fmt.Errorf("Parameter rollback need true or false")
+		}
+		if rollback {
+			return rollbackParameter(clientInstance, dbid, auth)
+		}
+	}
+	parameterType := strings.ToLower(options["type"])
+	switch parameterType {
+	case "":
+		parameterType = "static"
+	case "static", "dynamic":
+	default:
+		fmt.Println("Please add parameter type=static or type=dynamic")
+		return fmt.Errorf("Please add parameter type=static or type=dynamic")
+	}
+	if input == "" {
+		fmt.Println("Please add option -input with the YAML or JSON parameter file")
+		return fmt.Errorf("Please add option -input with the YAML or JSON parameter file")
+	}
+	values, err := readParameterFile(input)
+	if err != nil {
+		fmt.Println(err.Error())
+		return err
+	}
+
+	before, err := getParameter(clientInstance, dbid, parameterType, auth)
+	if err != nil {
+		return err
+	}
+	current := make(map[string]string)
+	for _, p := range parameterList(before) {
+		current[parameterKey(p.name)] = p.value
+	}
+	changed := make([]parameterSet, 0)
+	for _, v := range values {
+		d := findParameter(v.name)
+		if d != nil && sameParameterValue(d, current[parameterKey(d.name)], v.value) {
+			continue
+		}
+		changed = append(changed, v)
+	}
+	if len(changed) == 0 {
+		fmt.Printf("All %d parameters of %s already set\n", len(values), input)
+		return nil
+	}
+	infos, err := getParameterInfo(clientInstance, dbid, auth)
+	if err != nil {
+		return err
+	}
+	body, names, err := parameterBody(changed, parameterType, infos)
+	if err != nil {
+		return err
+	}
+	err = snapshotParameter(clientInstance, dbid, fmt.Sprintf("%s %s %s", parameterApplyReason, parameterType, input), auth)
+	if err != nil {
+		return err
+	}
+	err = postParameter(clientInstance, dbid, parameterType, body, auth)
+	if err != nil {
+		return err
+	}
+	after, err := getParameter(clientInstance, dbid, parameterType, auth)
+	if err != nil {
+		return err
+	}
+	printParameterChanges(before, after, names)
+	fmt.Println()
+	fmt.Println("Previous values saved in the parameter history, use -param rollback=true to restore them")
+	return nil
+}
+
+// rollbackParameter restore the parameter snapshot taken before the last applyparameter
+func rollbackParameter(clientInstance *client.AdabasAdmin, dbid int, auth runtime.ClientAuthInfoWriter) error {
+	snapshots, err := readSnapshots(historyServer(clientInstance), dbid)
+	if err != nil {
+		return err
+	}
+	version, parameterType, err := rollbackVersion(snapshots)
+	if err != nil {
+		fmt.Println(err.Error())
+		return err
+	}
+	snapshot := snapshots[version-1]
+	restore := snapshot.parameters(parameterType)
+	if restore == nil {
+		fmt.Printf("Version %d contains no %s parameters\n", version, parameterType)
+		return fmt.Errorf("Version %d contains no %s parameters", version, parameterType)
+	}
+	fmt.Printf("Roll back %s parameter change of %s\n", parameterType, snapshot.Time.Format(time.RFC3339))
+	return restoreSnapshot(clientInstance, dbid, restore, parameterType, fmt.Sprintf("version %d", version),
+		parameterRollbackReason, auth)
+}
+
+// rollbackVersion search the snapshot taken before the last applyparameter, returns the version
+// and the parameter type applied
+func rollbackVersion(snapshots []*parameterSnapshot) (int, string, error) {
+	for i := len(snapshots) - 1; i >= 0; i-- {
+		reason := strings.Fields(snapshots[i].Reason)
+		switch {
+		case snapshots[i].Reason == parameterRollbackReason:
+			return 0, "", fmt.Errorf("Last parameter change already rolled back")
+		case len(reason) > 1 && reason[0] == parameterApplyReason && (reason[1] == "static" || reason[1] == "dynamic"):
+			return i + 1, reason[1], nil
+		}
+	}
+	return 0, "", fmt.Errorf("No parameter change available to roll back")
+}
@@ -0,0 +1,281 @@
+/*
+* Copyright © 2018 Software AG, Darmstadt, Germany and/or its licensors
+*
+* SPDX-License-Identifier: Apache-2.0
+*
+*   Licensed under the Apache License, Version 2.0 (the "License");
+*   you may not use this file except in compliance with the License.
+*   You may obtain a copy of the License at
+*
+*       http://www.apache.org/licenses/LICENSE-2.0
+*
+*   Unless required by applicable law or agreed to in writing, software
+*   distributed under the License is distributed on an "AS IS" BASIS,
+*   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*   See the License for the specific language governing permissions and
+*   limitations under the License.
+*
+ */
+
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-openapi/runtime"
+	"softwareag.com/client"
+	"softwareag.com/client/online_offline"
+	"softwareag.com/models"
+)
+
+// parameterHistory local history of the parameter snapshots
+const parameterHistory = "parameter"
+
+// parameterSnapshot version of the static and dynamic parameters of a database
+type parameterSnapshot struct {
+	Time    time.Time                  `json:"time"`
+	Reason  string                     `json:"reason"`
+	Static  *models.ParameterParameter `json:"static"`
+	Dynamic *models.ParameterParameter `json:"dynamic"`
+}
+
+// parameters snapshot parameters of the given type
+func (snapshot *parameterSnapshot) parameters(parameterType string) *models.ParameterParameter {
+	if parameterType == "dynamic" {
+		return snapshot.Dynamic
+	}
+	return snapshot.Static
+}
+
+// snapshotParameter read the static and dynamic parameters and store them as new version
+// in the local parameter history
+func snapshotParameter(clientInstance *client.AdabasAdmin, dbid int, reason string, auth runtime.ClientAuthInfoWriter) error {
+	static, err := getParameter(clientInstance, dbid, "static", auth)
+	if err != nil {
+		return err
+	}
+	dynamic, err := getParameter(clientInstance, dbid, "dynamic", auth)
+	if err != nil {
+		return err
+	}
+	return appendHistory(parameterHistory, historyServer(clientInstance), dbid, &parameterSnapshot{Time: time.Now(), Reason: reason,
+		Static: static, Dynamic: dynamic})
+}
+
+// readSnapshots read all parameter versions of the database, the first version is version 1
+func readSnapshots(server string, dbid int) ([]*parameterSnapshot, error) {
+	entries, err := readHistory(parameterHistory, server, dbid)
+	if err != nil {
+		return nil, err
+	}
+	snapshots := make([]*parameterSnapshot, 0, len(entries))
+	for _, e := range entries {
+		s := &parameterSnapshot{}
+		err = json.Unmarshal(e, s)
+		if err != nil {
+			fmt.Println("Error parsing history:", err)
+			return nil, err
+		}
+		snapshots = append(snapshots, s)
+	}
+	return snapshots, nil
+}
+
+// changedParameters names of the parameters with different values
+func changedParameters(a, b *models.ParameterParameter) []string {
+	if a == nil || b == nil {
+		return []string{}
+	}
+	names := make([]string, 0)
+	bList := parameterList(b)
+	for i, p := range parameterList(a) {
+		if normalizeParameter(p.name, p.value) != normalizeParameter(bList[i].name, bList[i].value) {
+			names = append(names, p.name)
+		}
+	}
+	return names
+}
+
+// snapshotVersion parse the version number of the parameter history
+func snapshotVersion(value string, snapshots []*parameterSnapshot) (int, error) {
+	version, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || version < 1 || version > len(snapshots) {
+		return 0, fmt.Errorf("Version %s not valid, need version between 1 and %d", value, len(snapshots))
+	}
+	return version, nil
+}
+
+// Snapshot store the current static and dynamic parameters as new version in the local history
+func Snapshot(clientInstance *client.AdabasAdmin, dbid int, param string, auth runtime.ClientAuthInfoWriter) error {
+	if dbid < 1 {
+		fmt.Println("Please add option -dbid Adabas database id")
+		return fmt.Errorf("Please add option -dbid Adabas database id")
+	}
+	reason := "snapshot"
+	if param != "" {
+		reason = param
+	}
+	err := snapshotParameter(clientInstance, dbid, reason, auth)
+	if err != nil {
+		return err
+	}
+	snapshots, err := readSnapshots(historyServer(clientInstance), dbid)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Parameter version %d of database %03d stored\n", len(snapshots), dbid)
+	return nil
+}
+
+// ParameterHistory list the parameter versions of the local history. The parameter <version> shows the
+// differences to the previous version, <version1>:<version2> the differences between two versions.
+func ParameterHistory(clientInstance *client.AdabasAdmin, dbid int, param string) error {
+	if dbid < 1 {
+		fmt.Println("Please add option -dbid Adabas database id")
+		return fmt.Errorf("Please add option -dbid Adabas database id")
+	}
+	snapshots, err := readSnapshots(historyServer(clientInstance), dbid)
+	if err != nil {
+		return err
+	}
+	if len(snapshots) == 0 {
+		fmt.Printf("No parameter history of database %03d available\n", dbid)
+		return nil
+	}
+	if strings.TrimSpace(param) == "" {
+		fmt.Printf("\nParameter history of database %03d:\n\n", dbid)
+		fmt.Printf(" %7s %-20s %8s %8s  %s\n", "Version", "Time", "Static", "Dynamic", "Reason")
+		for i, s := range snapshots {
+			static, dynamic := "-", "-"
+			if i > 0 {
+				static = strconv.Itoa(len(changedParameters(snapshots[i-1].Static, s.Static)))
+				dynamic = strconv.Itoa(len(changedParameters(snapshots[i-1].Dynamic, s.Dynamic)))
+			}
+			fmt.Printf(" %7d %-20s %8s %8s  %s\n", i+1, s.Time.Format("2006-01-02 15:04:05"), static, dynamic, s.Reason)
+		}
+		fmt.Println()
+		fmt.Println(" Static and dynamic show the number of parameters changed to the previous version")
+		return nil
+	}
+	v := strings.Split(param, ":")
+	to, err := snapshotVersion(v[len(v)-1], snapshots)
+	from := to - 1
+	if err == nil && len(v) == 2 {
+		from, err = snapshotVersion(v[0], snapshots)
+	}
+	if err == nil && (len(v) > 2 || from < 1) {
+		err = fmt.Errorf("Please add parameter <version> or <version1>:<version2> to compare")
+	}
+	if err != nil {
+		fmt.Println(err.Error())
+		return err
+	}
+	for _, t := range []string{"static", "dynamic"} {
+		before := snapshots[from-1].parameters(t)
+		after := snapshots[to-1].parameters(t)
+		names := changedParameters(before, after)
+		fmt.Printf("\n Adabas %s parameter version %d -> %d:\n", t, from, to)
+		if len(names) == 0 {
+			fmt.Println()
+			fmt.Println(" No differences")
+			continue
+		}
+		printParameterChanges(before, after, names)
+	}
+	return nil
+}
+
+// ParameterRestore reapply the parameters of a version in the local history. The parameter
+// is version=<version> optional followed by type=static or type=dynamic.
+func ParameterRestore(clientInstance *client.AdabasAdmin, dbid int, param string, auth runtime.ClientAuthInfoWriter) error {
+	if dbid < 1 {
+		fmt.Println("Please add option -dbid Adabas database id")
+		return fmt.Errorf("Please add option -dbid Adabas database id")
+	}
+	options, err := parseKeyValues(param, "version", "type")
+	if err != nil {
+		fmt.Println(err.Error())
+		return err
+	}
+	parameterType := strings.ToLower(options["type"])
+	switch parameterType {
+	case "":
+		parameterType = "static"
+	case "static", "dynamic":
+	default:
+		fmt.Println("Please add parameter type=static or type=dynamic")
+		return fmt.Errorf("Please add parameter type=static or type=dynamic")
+	}
+	snapshots, err := readSnapshots(historyServer(clientInstance), dbid)
+	if err != nil {
+		return err
+	}
+	if len(snapshots) == 0 {
+		fmt.Printf("No parameter history of database %03d available\n", dbid)
+		return fmt.Errorf("No parameter history available")
+	}
+	version, err := snapshotVersion(options["version"], snapshots)
+	if err != nil {
+		fmt.Println(err.Error())
+		return err
+	}
+	restore := snapshots[version-1].parameters(parameterType)
+	if restore == nil {
+		fmt.Printf("Version %d contains no %s parameters\n", version, parameterType)
+		return fmt.Errorf("Version %d contains no %s parameters", version, parameterType)
+	}
+
+	return restoreSnapshot(clientInstance, dbid, restore, parameterType, fmt.Sprintf("version %d", version),
+		fmt.Sprintf("parameterrestore version %d", version), auth)
+}
+
+// restoreSnapshot reapply the parameters of the snapshot differing from the current parameters,
+// the current parameters are stored as new version with the given reason before
+func restoreSnapshot(clientInstance *client.AdabasAdmin, dbid int, restore *models.ParameterParameter, parameterType string,
+	version string, reason string, auth runtime.ClientAuthInfoWriter) error {
+	current, err := getParameter(clientInstance, dbid, parameterType, auth)
+	if err != nil {
+		return err
+	}
+	changed := changedParameters(current, restore)
+	if len(changed) == 0 {
+		fmt.Printf("Adabas %s parameters already equal to %s\n", parameterType, version)
+		return nil
+	}
+	infos, err := getParameterInfo(clientInstance, dbid, auth)
+	if err != nil {
+		return err
+	}
+	params := online_offline.NewPutAdabasParameterParams()
+	params.Dbid = float64(dbid)
+	params.Type = parameterType
+	names := make([]string, 0)
+	invalid := 0
+	for _, v := range serverParameterSets(restore, changed) {
+		d := findParameter(v.name)
+		if d == nil {
+			fmt.Printf("Parameter %s cannot be restored\n", v.name)
+			invalid++
+			continue
+		}
+		if _, verr := d.apply(params, v.value, infos[parameterKey(d.name)]); verr != nil {
+			fmt.Println(verr.Error())
+			invalid++
+			continue
+		}
+		names = append(names, d.name)
+	}
+	if invalid > 0 {
+		return fmt.Errorf("%d parameters cannot be restored", invalid)
+	}
+	err = snapshotParameter(clientInstance, dbid, reason, auth)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Restore %d %s parameters of %s\n", len(names), parameterType, version)
+	return putParameter(clientInstance, dbid, params, names, auth)
+}
@@ -0,0 +1,252 @@
+/*
+* Copyright © 2018 Software AG, Darmstadt, Germany and/or its licensors
+*
+* SPDX-License-Identifier: Apache-2.0
+*
+*   Licensed under the Apache License, Version 2.0 (the "License");
+*   you may not use this file except in compliance with the License.
+*   You may obtain a copy of the License at
+*
+*       http://www.apache.org/licenses/LICENSE-2.0
+*
+*   Unless required by applicable law or agreed to in writing, software
+*   distributed under the License is distributed on an "AS IS" BASIS,
+*   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*   See the License for the specific language governing permissions and
+*   limitations under the License.
+*
+ */
+
+package database
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-openapi/runtime"
+	"softwareag.com/client"
+	"softwareag.com/models"
+)
+
+// profileMinInterval shortest sampling interval of the profiler
+const profileMinInterval = 10 * time.Millisecond
+
+// profileRankLimit number of entries shown in each ranking
+const profileRankLimit = 10
+
+// profileBarWidth width of the histogram bars
+const profileBarWidth = 40
+
+// profileSample sample of the thread table and command queue
+type profileSample struct {
+	time     time.Time
+	threads  []*models.Thread
+	commands []*models.CommandQueueEntry
+}
+
+// rankEntry entry of a ranking with the number of occurrences
+type rankEntry struct {
+	name  string
+	count int
+}
+
+// profileBucket time interval of the histogram with the average active threads and queued commands
+type profileBucket struct {
+	start    time.Time
+	threads  float64
+	commands float64
+}
+
+// profileRanking rankings of command codes, files and users
+type profileRanking struct {
+	threadSamples  int
+	commandSamples int
+	threadCommand  []*rankEntry
+	threadFile     []*rankEntry
+	queueCommand   []*rankEntry
+	queueFile      []*rankEntry
+	queueUser      []*rankEntry
+}
+
+// activeThread check if the thread is processing a command
+func activeThread(t *models.Thread) bool {
+	return strings.TrimSpace(t.CommandCode) != ""
+}
+
+// rank sort the counts descending, equal counts are sorted by name
+func rank(counts map[string]int) []*rankEntry {
+	list := make([]*rankEntry, 0, len(counts))
+	for n, c := range counts {
+		list = append(list, &rankEntry{name: n, count: c})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].count != list[j].count {
+			return list[i].count > list[j].count
+		}
+		return list[i].name < list[j].name
+	})
+	return list
+}
+
+// rankProfile aggregate the samples into rankings
+func rankProfile(samples []*profileSample) *profileRanking {
+	ranking := &profileRanking{}
+	threadCommand := make(map[string]int)
+	threadFile := make(map[string]int)
+	queueCommand := make(map[string]int)
+	queueFile := make(map[string]int)
+	queueUser := make(map[string]int)
+	for _, s := range samples {
+		for _, t := range s.threads {
+			if !activeThread(t) {
+				continue
+			}
+			ranking.threadSamples++
+			threadCommand[strings.TrimSpace(t.CommandCode)]++
+			threadFile[strconv.FormatInt(t.File, 10)]++
+		}
+		for _, c := range s.commands {
+			ranking.commandSamples++
+			queueCommand[strings.TrimSpace(c.CommandCode)]++
+			queueFile[strconv.FormatInt(c.File, 10)]++
+			queueUser[userKey(c.User)]++
+		}
+	}
+	ranking.threadCommand = rank(threadCommand)
+	ranking.threadFile = rank(threadFile)
+	ranking.queueCommand = rank(queueCommand)
+	ranking.queueFile = rank(queueFile)
+	ranking.queueUser = rank(queueUser)
+	return ranking
+}
+
+// profileHistogram average active threads and queued commands over time in the given number of buckets
+func profileHistogram(samples []*profileSample, buckets int) []*profileBucket {
+	histogram := make([]*profileBucket, 0, buckets)
+	if len(samples) == 0 || buckets < 1 {
+		return histogram
+	}
+	first := samples[0].time
+	window := samples[len(samples)-1].time.Sub(first)
+	width := window/time.Duration(buckets) + 1
+	counts := make([]int, buckets)
+	for i := 0; i < buckets; i++ {
+		histogram = append(histogram, &profileBucket{start: first.Add(time.Duration(i) * width)})
+	}
+	for _, s := range samples {
+		b := int(s.time.Sub(first) / width)
+		if b >= buckets {
+			b = buckets - 1
+		}
+		counts[b]++
+		for _, t := range s.threads {
+			if activeThread(t) {
+				histogram[b].threads++
+			}
+		}
+		histogram[b].commands += float64(len(s.commands))
+	}
+	for i, h := range histogram {
+		if counts[i] > 0 {
+			h.threads /= float64(counts[i])
+			h.commands /= float64(counts[i])
+		}
+	}
+	return histogram
+}
+
+func printRanking(title string, list []*rankEntry, total int) {
+	fmt.Printf("\n %s:\n", title)
+	if total == 0 {
+		fmt.Println("  none")
+		return
+	}
+	for i, e := range list {
+		if i == profileRankLimit {
+			break
+		}
+		fmt.Printf("  %-24s %8d %6.1f%%\n", e.name, e.count, float64(e.count)*100/float64(total))
+	}
+}
+
+// Profile sample the thread table and the command queue over a time window and report which command
+// codes, files and users dominate. The parameter defines the window and sample interval in seconds and
+// the number of histogram intervals, like duration=60,interval=0.5,buckets=12.
+func Profile(clientInstance *client.AdabasAdmin, dbid int, param string, auth runtime.ClientAuthInfoWriter) error {
+	if dbid < 1 {
+		fmt.Println("Please add option -dbid Adabas database id")
+		return fmt.Errorf("Please add option -dbid Adabas database id")
+	}
+	values, err := parseKeyValues(param, "duration", "interval", "buckets")
+	if err != nil {
+		fmt.Println(err.Error())
+		return err
+	}
+	settings := map[string]float64{"duration": 60, "interval": 0.5, "buckets": 12}
+	for k, v := range values {
+		f, perr := strconv.ParseFloat(v, 64)
+		if perr != nil || f <= 0 {
+			fmt.Printf("Parameter %s=%s not valid, need positive number\n", k, v)
+			return fmt.Errorf("Parameter %s=%s not valid", k, v)
+		}
+		settings[k] = f
+	}
+	duration := time.Duration(settings["duration"] * float64(time.Second))
+	interval := time.Duration(settings["interval"] * float64(time.Second))
+	if interval < profileMinInterval {
+		fmt.Printf("Parameter interval need at least %v\n", profileMinInterval)
+		return fmt.Errorf("Parameter interval need at least %v", profileMinInterval)
+	}
+
+	fmt.Printf("\nProfiling database %03d for %v every %v\n", dbid, duration, interval)
+	samples := make([]*profileSample, 0)
+	end := time.Now().Add(duration)
+	for time.Now().Before(end) {
+		sample := &profileSample{time: time.Now()}
+		sample.threads, err = getThreadTable(clientInstance, dbid, auth)
+		if err != nil {
+			return err
+		}
+		sample.commands, err = getCommandQueue(clientInstance, dbid, auth)
+		if err != nil {
+			return err
+		}
+		samples = append(samples, sample)
+		time.Sleep(interval - time.Since(sample.time)%interval)
+	}
+
+	ranking := rankProfile(samples)
+	fmt.Printf("\n%d samples, %d active thread and %d queued command occurrences\n", len(samples),
+		ranking.threadSamples, ranking.commandSamples)
+	printRanking("Active threads per command code", ranking.threadCommand, ranking.threadSamples)
+	printRanking("Active threads per file", ranking.threadFile, ranking.threadSamples)
+	printRanking("Queued commands per command code", ranking.queueCommand, ranking.commandSamples)
+	printRanking("Queued commands per file", ranking.queueFile, ranking.commandSamples)
+	printRanking("Queued commands per user", ranking.queueUser, ranking.commandSamples)
+
+	histogram := profileHistogram(samples, int(settings["buckets"]))
+	peak := 0.0
+	for _, h := range histogram {
+		if h.threads > peak {
+			peak = h.threads
+		}
+		if h.commands > peak {
+			peak = h.commands
+		}
+	}
+	fmt.Printf("\n Average active threads (#) and queued commands (=) over time:\n")
+	for _, h := range histogram {
+		threads, commands := 0, 0
+		if peak > 0 {
+			threads = int(h.threads * profileBarWidth / peak)
+			commands = int(h.commands * profileBarWidth / peak)
+		}
+		fmt.Printf("  %s %6.1f %-*s|\n", h.start.Format("15:04:05"), h.threads, profileBarWidth, strings.Repeat("#", threads))
+		fmt.Printf("  %8s %6.1f %-*s|\n", "", h.commands, profileBarWidth, strings.Repeat("=", commands))
+	}
+	fmt.Println()
+	return nil
+}
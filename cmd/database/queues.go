@@ -26,10 +26,11 @@ import (
 	"github.com/go-openapi/runtime"
 	"softwareag.com/client"
 	"softwareag.com/client/online"
+	"softwareag.com/models"
 )
 
-// UserQueue display all user queue entries
-func UserQueue(clientInstance *client.AdabasAdmin, dbid int, auth runtime.ClientAuthInfoWriter) error {
+// getUserQueue read all user queue entries
+func getUserQueue(clientInstance *client.AdabasAdmin, dbid int, auth runtime.ClientAuthInfoWriter) ([]*models.UserQueueEntry, error) {
 	params := online.NewGetDatabaseUserQueueParams()
 	rfc3339 := true
 	params.Rfc3339 = &rfc3339
@@ -44,44 +45,94 @@ func UserQueue(clientInstance *client.AdabasAdmin, dbid int, auth runtime.Client
 		default:
 			fmt.Println("Error:", err)
 		}
+		return nil, err
+	}
+	if resp.Payload.UserQueue == nil {
+		return []*models.UserQueueEntry{}, nil
+	}
+	return resp.Payload.UserQueue.UserQueueEntry, nil
+}
+
+// UserQueue display the user queue entries, optional filtered by user, node, terminal,
+// inactivity, open transaction and file in use
+func UserQueue(clientInstance *client.AdabasAdmin, dbid int, param string, auth runtime.ClientAuthInfoWriter) error {
+	filter, _, err := parseUserFilter(param)
+	if err != nil {
+		fmt.Println(err.Error())
+		return err
+	}
+	userQueue, _, err := filterUsers(clientInstance, dbid, filter, auth)
+	if err != nil {
 		return err
 	}
-	userQueue := resp.Payload.UserQueue
 
 	fmt.Println()
 	fmt.Println(" User queue entries:")
 	fmt.Println()
+	printUserQueue(userQueue)
+	return nil
+}
+
+// printUserQueue print the user queue entries
+func printUserQueue(userQueue []*models.UserQueueEntry) {
 	fmt.Printf(" %3s %-10s %-8s %-8s %-28s %-8s %-8s %-8s\n", "Id", "Es ID", "Node Id", "Login Id", "Timestamp", "User", "Flags", "ETFlags")
-	for _, u := range userQueue.UserQueueEntry {
+	for _, u := range userQueue {
 		fmt.Printf(" %3d %10d %-8s %-8s %-8s %-8s %-8s %-8s\n", u.UqID, u.UID.ID, u.UID.Node, u.UID.Terminal,
 			u.UID.Timestamp, u.User, u.Flags, u.EtFlags)
 	}
-	return nil
 }
 
-// UserDetails retrieve user queue entry details
-func UserDetails(clientInstance *client.AdabasAdmin, dbid int, param string, auth runtime.ClientAuthInfoWriter) error {
+// readUserDetail read the details of the user queue entry without reporting errors
+func readUserDetail(clientInstance *client.AdabasAdmin, dbid int, queueID int64, auth runtime.ClientAuthInfoWriter) (*models.UserQueueDetail, error) {
 	params := online.NewGetUserQueueDetailParams()
+	rfc3339 := true
+	params.Rfc3339 = &rfc3339
 	params.Dbid = float64(dbid)
-	qid, qerr := strconv.Atoi(param)
-	if qerr != nil {
-		return qerr
-	}
-	params.Queueid = float64(qid)
+	params.Queueid = float64(queueID)
 
 	resp, err := clientInstance.Online.GetUserQueueDetail(params, auth)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Payload, nil
+}
+
+// getUserDetail read the details of the user queue entry
+func getUserDetail(clientInstance *client.AdabasAdmin, dbid int, queueID int64, auth runtime.ClientAuthInfoWriter) (*models.UserQueueDetail, error) {
+	detail, err := readUserDetail(clientInstance, dbid, queueID, auth)
 	if err != nil {
 		switch err.(type) {
-		case *online.GetDatabaseCommandQueueBadRequest:
+		case *online.GetUserQueueDetailBadRequest:
 			response := err.(*online.GetUserQueueDetailBadRequest)
 			fmt.Println(response.Payload.Error.Code, ":", response.Payload.Error.Message)
 		default:
 			fmt.Println("Error:", err)
 		}
+		return nil, err
+	}
+	return detail, nil
+}
+
+// userGone check if the error reports that the user queue entry is not found, the user left the
+// user queue after it was read
+func userGone(err error) bool {
+	_, ok := err.(*online.GetUserQueueDetailBadRequest)
+	return ok
+}
+
+// UserDetails retrieve user queue entry details
+func UserDetails(clientInstance *client.AdabasAdmin, dbid int, param string, auth runtime.ClientAuthInfoWriter) error {
+	qid, qerr := strconv.Atoi(param)
+	if qerr != nil {
+		fmt.Println("Please add parameter with the user queue id")
+		return qerr
+	}
+	detail, err := getUserDetail(clientInstance, dbid, int64(qid), auth)
+	if err != nil {
 		return err
 	}
 	fmt.Println()
-	userDetails := resp.Payload.UserQueueDetail.DetailEntry[0]
+	userDetails := detail.UserQueueDetail.DetailEntry[0]
 	fmt.Printf(" Got user queue details of queue id %v:\n", userDetails.UqID)
 	fmt.Printf("%20s : %s\n", "User", userDetails.User)
 	fmt.Printf("%20s :\n", "Adabas ID")
@@ -91,40 +142,36 @@ func UserDetails(clientInstance *client.AdabasAdmin, dbid int, param string, aut
 	fmt.Printf("%21s : %s\n", " Timestamp", userDetails.UID.Timestamp)
 	fmt.Printf("%20s : %s\n", "Flags", userDetails.Flags)
 	fmt.Printf("%20s : %s\n", "ET Flags", userDetails.EtFlags)
-	fmt.Printf("%20s : %s\n", "Start session", resp.Payload.StartSession)
-	fmt.Printf("%20s : %s\n", "Start transaction", resp.Payload.StartTransaction)
-	fmt.Printf("%20s : %s\n", "Last activity", resp.Payload.LastActivity)
-	fmt.Printf("%20s : %d\n", "TT Limit", resp.Payload.TTLimit)
-	fmt.Printf("%20s : %d\n", "TNA Limit", resp.Payload.TNALimit)
-	fmt.Printf("%20s : %d\n", "ISN lists", resp.Payload.ISNLists)
-	fmt.Printf("%20s : %d\n", "ISN in hold", resp.Payload.ISNHold)
+	fmt.Printf("%20s : %s\n", "Start session", detail.StartSession)
+	fmt.Printf("%20s : %s\n", "Start transaction", detail.StartTransaction)
+	fmt.Printf("%20s : %s\n", "Last activity", detail.LastActivity)
+	fmt.Printf("%20s : %d\n", "TT Limit", detail.TTLimit)
+	fmt.Printf("%20s : %d\n", "TNA Limit", detail.TNALimit)
+	fmt.Printf("%20s : %d\n", "ISN lists", detail.ISNLists)
+	fmt.Printf("%20s : %d\n", "ISN in hold", detail.ISNHold)
 	fmt.Printf("%20s :\n", "Files in use")
-	for f := range resp.Payload.Files {
+	for _, f := range detail.Files {
 		if f > 0 {
 			fmt.Printf("%20s : %d\n", " ", f)
 		}
 	}
-	fmt.Printf("%20s : %d\n", "Command count:", resp.Payload.CommandCount)
-	fmt.Printf("%20s : %d\n", "Transaction count:", resp.Payload.TransactionCount)
-	fmt.Printf("%20s : %d\n", "User encoding:", resp.Payload.UserEncoding)
+	fmt.Printf("%20s : %d\n", "Command count:", detail.CommandCount)
+	fmt.Printf("%20s : %d\n", "Transaction count:", detail.TransactionCount)
+	fmt.Printf("%20s : %d\n", "User encoding:", detail.UserEncoding)
 	fmt.Println()
 	return nil
 }
 
-// DeleteUser stop user
-func DeleteUser(clientInstance *client.AdabasAdmin, dbid int, param string, auth runtime.ClientAuthInfoWriter) error {
+// stopUser stop the user queue entry
+func stopUser(clientInstance *client.AdabasAdmin, dbid int, queueID int64, auth runtime.ClientAuthInfoWriter) error {
 	params := online.NewStopUserQueueEntryParams()
 	params.Dbid = float64(dbid)
-	qid, qerr := strconv.Atoi(param)
-	if qerr != nil {
-		return qerr
-	}
-	params.Queueid = float64(qid)
+	params.Queueid = float64(queueID)
 
-	resp, err := clientInstance.Online.StopUserQueueEntry(params, auth)
+	_, err := clientInstance.Online.StopUserQueueEntry(params, auth)
 	if err != nil {
 		switch err.(type) {
-		case *online.GetDatabaseCommandQueueBadRequest:
+		case *online.StopUserQueueEntryBadRequest:
 			response := err.(*online.StopUserQueueEntryBadRequest)
 			fmt.Println(response.Payload.Error.Code, ":", response.Payload.Error.Message)
 		default:
@@ -132,15 +179,41 @@ func DeleteUser(clientInstance *client.AdabasAdmin, dbid int, param string, auth
 		}
 		return err
 	}
+	return nil
+}
+
+// stopUserIfPresent stop the user, a user that already left the user queue is no error. Returns
+// false if the user was not stopped because it left.
+func stopUserIfPresent(clientInstance *client.AdabasAdmin, dbid int, queueID int64, auth runtime.ClientAuthInfoWriter) (bool, error) {
+	err := stopUser(clientInstance, dbid, queueID, auth)
+	if err == nil {
+		return true, nil
+	}
+	if _, derr := readUserDetail(clientInstance, dbid, queueID, auth); userGone(derr) {
+		fmt.Printf("User %d already left the user queue\n", queueID)
+		return false, nil
+	}
+	return false, err
+}
+
+// DeleteUser stop user given by user queue id, or all users matching the filter
+func DeleteUser(clientInstance *client.AdabasAdmin, dbid int, param string, auth runtime.ClientAuthInfoWriter) error {
+	qid, qerr := strconv.Atoi(param)
+	if qerr != nil {
+		return stopUsers(clientInstance, dbid, param, auth)
+	}
+	err := stopUser(clientInstance, dbid, int64(qid), auth)
+	if err != nil {
+		return err
+	}
 	fmt.Println()
-	fmt.Printf(" Stop of user %v in user queue initiated\n", params.Queueid)
+	fmt.Printf(" Stop of user %v in user queue initiated\n", qid)
 	fmt.Println()
-	fmt.Println(resp)
 	return nil
 }
 
-// CommandQueue display all command queue entries
-func CommandQueue(clientInstance *client.AdabasAdmin, dbid int, auth runtime.ClientAuthInfoWriter) error {
+// getCommandQueue read all command queue entries
+func getCommandQueue(clientInstance *client.AdabasAdmin, dbid int, auth runtime.ClientAuthInfoWriter) ([]*models.CommandQueueEntry, error) {
 	params := online.NewGetDatabaseCommandQueueParams()
 	rfc3339 := true
 	params.Rfc3339 = &rfc3339
@@ -154,6 +227,18 @@ func CommandQueue(clientInstance *client.AdabasAdmin, dbid int, auth runtime.Cli
 		default:
 			fmt.Println("Error:", err)
 		}
+		return nil, err
+	}
+	if resp.Payload.CommandQueue == nil {
+		return []*models.CommandQueueEntry{}, nil
+	}
+	return resp.Payload.CommandQueue.Commands, nil
+}
+
+// CommandQueue display all command queue entries
+func CommandQueue(clientInstance *client.AdabasAdmin, dbid int, auth runtime.ClientAuthInfoWriter) error {
+	commands, err := getCommandQueue(clientInstance, dbid, auth)
+	if err != nil {
 		return err
 	}
 
@@ -161,14 +246,14 @@ func CommandQueue(clientInstance *client.AdabasAdmin, dbid int, auth runtime.Cli
 	fmt.Println(" Command queue entries:")
 	fmt.Println()
 	fmt.Printf(" %3s  %-8s  %-8s  %-10s  %-3s  %-8s  %-8s\n", "No", "Node Id", "Login Id", "ES Id", "Cmd", "File", "Status")
-	for _, c := range resp.Payload.CommandQueue.Commands {
+	for _, c := range commands {
 		fmt.Printf(" %3d  %-8s  %-8s  %-10d  %-3s  %-8d  %-s\n", c.CommID, c.User.Node, c.User.Terminal, c.User.ID, c.CommandCode, c.File, c.Flags)
 	}
 	return nil
 }
 
-// HoldQueue display all hold queue entries
-func HoldQueue(clientInstance *client.AdabasAdmin, dbid int, auth runtime.ClientAuthInfoWriter) error {
+// getHoldQueue read all hold queue entries
+func getHoldQueue(clientInstance *client.AdabasAdmin, dbid int, auth runtime.ClientAuthInfoWriter) ([]*models.HoldQueueEntry, error) {
 	params := online.NewGetDatabaseHoldQueueParams()
 	rfc3339 := true
 	params.Rfc3339 = &rfc3339
@@ -182,6 +267,15 @@ func HoldQueue(clientInstance *client.AdabasAdmin, dbid int, auth runtime.Client
 		default:
 			fmt.Println("Error:", err)
 		}
+		return nil, err
+	}
+	return resp.Payload.HoldQueue, nil
+}
+
+// HoldQueue display all hold queue entries
+func HoldQueue(clientInstance *client.AdabasAdmin, dbid int, auth runtime.ClientAuthInfoWriter) error {
+	holdQueue, err := getHoldQueue(clientInstance, dbid, auth)
+	if err != nil {
 		return err
 	}
 
@@ -189,8 +283,13 @@ func HoldQueue(clientInstance *client.AdabasAdmin, dbid int, auth runtime.Client
 	fmt.Println(" Hold queue entries:")
 	fmt.Println()
 	fmt.Printf("   Id Node Id   Login Id     ES Id     User Id  File           ISN Locks  Flg\n")
-	for _, c := range resp.Payload.HoldQueue {
-		fmt.Printf(" %3d  %-8s  %-8s     %3d  %3s  %-3d  %d %s %s\n", c.HqCommid, c.Hid[0].Node, c.Hid[0].Terminal, c.Hid[0].ID, c.User, c.File, c.Isn, c.Locks, c.Flags)
+	for _, c := range holdQueue {
+		if len(c.Hid) == 0 {
+			fmt.Printf(" %3d  %-8s  %-8s     %3s  %3s  %-3d  %d %s %s\n", c.HqCommid, "", "", "", c.User, c.File, c.Isn, c.Locks, c.Flags)
+		}
+		for _, h := range c.Hid {
+			fmt.Printf(" %3d  %-8s  %-8s     %3d  %3s  %-3d  %d %s %s\n", c.HqCommid, h.Node, h.Terminal, h.ID, c.User, c.File, c.Isn, c.Locks, c.Flags)
+		}
 	}
 	return nil
 }
@@ -0,0 +1,204 @@
+/*
+* Copyright © 2018 Software AG, Darmstadt, Germany and/or its licensors
+*
+* SPDX-License-Identifier: Apache-2.0
+*
+*   Licensed under the Apache License, Version 2.0 (the "License");
+*   you may not use this file except in compliance with the License.
+*   You may obtain a copy of the License at
+*
+*       http://www.apache.org/licenses/LICENSE-2.0
+*
+*   Unless required by applicable law or agreed to in writing, software
+*   distributed under the License is distributed on an "AS IS" BASIS,
+*   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*   See the License for the specific language governing permissions and
+*   limitations under the License.
+*
+ */
+
+package database
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-openapi/runtime"
+	"softwareag.com/client"
+	"softwareag.com/models"
+)
+
+// restartResult result of the restart of one database in a rolling restart
+type restartResult struct {
+	dbid     int
+	shutdown time.Duration
+	start    time.Duration
+	result   string
+	err      error
+}
+
+// parseDbids parse a database id list like 24,25,26
+func parseDbids(param string) ([]int, error) {
+	dbids := make([]int, 0)
+	for _, v := range strings.Split(param, ",") {
+		if strings.TrimSpace(v) == "" {
+			continue
+		}
+		dbid, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil || dbid < 1 || dbid > 65535 {
+			return nil, fmt.Errorf("Database id %s not valid", v)
+		}
+		for _, d := range dbids {
+			if d == dbid {
+				return nil, fmt.Errorf("Database id %d given twice", dbid)
+			}
+		}
+		dbids = append(dbids, dbid)
+	}
+	return dbids, nil
+}
+
+// highWaterProblems check the high water marks after a restart. The nucleus must be started after
+// the given time and no area may be exhausted.
+func highWaterProblems(highWater *models.HWMHighWater, since time.Time) []string {
+	problems := make([]string, 0)
+	if highWater == nil {
+		return append(problems, "no high water marks available")
+	}
+	started := time.Time(highWater.NucleusStartTime)
+	if started.Before(since) {
+		problems = append(problems, fmt.Sprintf("nucleus start time %s before restart", highWater.NucleusStartTime))
+	}
+	areas := []struct {
+		name string
+		size int64
+		mark *models.HighWaterEntries
+	}{
+		{"User Queue", highWater.UserQueueSize, highWater.UserQueueHighWaterMark},
+		{"Command Queue", highWater.CommandQueueSize, highWater.CommandQueueHighWaterMark},
+		{"Hold Queue", highWater.HoldQueueSize, highWater.HoldQueueHighWaterMark},
+		{"Client Queue", highWater.ClientQueueSize, highWater.ClientQueueHighWaterMark},
+		{"Threads", highWater.ThreadSize, highWater.ThreadsHighWaterMark},
+		{"Workpool", highWater.WorkpoolSize, highWater.WorkpoolHighWaterMark},
+		{"Buffer Pool", highWater.BufferpoolSize, highWater.BufferpoolHighWaterMark},
+	}
+	for _, a := range areas {
+		if a.mark != nil && a.size > 0 && a.mark.High >= a.size {
+			problems = append(problems, fmt.Sprintf("%s exhausted, high water %d of %d", a.name, a.mark.High, a.size))
+		}
+	}
+	return problems
+}
+
+// restartDatabase shutdown the database, wait until offline, start and wait until online. An offline
+// database is only started. The health of the restarted database is checked using nucleus log and
+// high water marks.
+func restartDatabase(clientInstance *client.AdabasAdmin, dbid int, timeout time.Duration, auth runtime.ClientAuthInfoWriter) *restartResult {
+	result := &restartResult{dbid: dbid}
+	fmt.Printf("\n=== Restart database %03d ===\n", dbid)
+	active, err := databaseActive(clientInstance, dbid, auth)
+	if err != nil {
+		result.result = err.Error()
+		result.err = err
+		return result
+	}
+	done := "restarted"
+	if active {
+		begin := time.Now()
+		if err = Operation(clientInstance, dbid, "shutdown", auth); err == nil {
+			err = waitState(clientInstance, dbid, false, timeout, auth)
+		}
+		result.shutdown = time.Since(begin)
+		if err != nil {
+			result.result = "shutdown failed: " + err.Error()
+			result.err = err
+			return result
+		}
+	} else {
+		fmt.Printf("Database %03d offline, only start it\n", dbid)
+		done = "started, database was offline"
+	}
+	begin := time.Now()
+	if err = Operation(clientInstance, dbid, "start", auth); err == nil {
+		err = waitState(clientInstance, dbid, true, timeout, auth)
+	}
+	result.start = time.Since(begin)
+	if err != nil {
+		result.result = "start failed: " + err.Error()
+		result.err = err
+		return result
+	}
+	lines, err := nucleusLogLines(clientInstance, dbid, auth)
+	if err != nil {
+		result.result = "nucleus log not available: " + err.Error()
+		result.err = err
+		return result
+	}
+	problems := make([]string, 0)
+	if e := nucleusErrors(lines); len(e) > 0 {
+		for _, l := range e {
+			fmt.Println(" " + l)
+		}
+		problems = append(problems, fmt.Sprintf("%d nucleus log errors", len(e)))
+	}
+	highWater, err := getHighWater(clientInstance, dbid, auth)
+	if err != nil {
+		result.result = "high water marks not available: " + err.Error()
+		result.err = err
+		return result
+	}
+	problems = append(problems, highWaterProblems(highWater, begin.Add(-time.Minute))...)
+	if len(problems) > 0 {
+		result.result = "unhealthy: " + strings.Join(problems, ", ")
+		result.err = fmt.Errorf("Database %03d unhealthy after restart", dbid)
+		return result
+	}
+	result.result = done
+	return result
+}
+
+// RollingRestart restart the databases given in the parameter one after another. The rollout
+// stops on the first database failing to restart or being unhealthy after the restart.
+func RollingRestart(clientInstance *client.AdabasAdmin, dbid int, param string, timeout int, auth runtime.ClientAuthInfoWriter) error {
+	dbids, err := parseDbids(param)
+	if err != nil {
+		fmt.Println(err.Error())
+		return err
+	}
+	if len(dbids) == 0 && dbid > 0 {
+		dbids = append(dbids, dbid)
+	}
+	if len(dbids) == 0 {
+		fmt.Println("Please add parameter with the list of database ids, like 24,25,26")
+		return fmt.Errorf("Please add parameter with the list of database ids")
+	}
+	results := make([]*restartResult, 0, len(dbids))
+	var failed *restartResult
+	for _, d := range dbids {
+		r := restartDatabase(clientInstance, d, time.Duration(timeout)*time.Second, auth)
+		results = append(results, r)
+		if r.err != nil {
+			failed = r
+			break
+		}
+	}
+
+	fmt.Printf("\nRolling restart report:\n\n")
+	fmt.Printf(" %4s  %10s  %10s  %s\n", "Dbid", "Shutdown", "Start", "Result")
+	for _, r := range results {
+		fmt.Printf("  %03d  %10v  %10v  %s\n", r.dbid, r.shutdown.Round(time.Second), r.start.Round(time.Second), r.result)
+	}
+	for _, d := range dbids[len(results):] {
+		fmt.Printf("  %03d  %10s  %10s  %s\n", d, "-", "-", "not restarted, rollout stopped")
+	}
+	fmt.Println()
+	if failed != nil {
+		if failed.err == ErrWaitTimeout {
+			return ErrWaitTimeout
+		}
+		return fmt.Errorf("Rolling restart stopped at database %03d", failed.dbid)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+/*
+* Copyright © 2018 Software AG, Darmstadt, Germany and/or its licensors
+*
+* SPDX-License-Identifier: Apache-2.0
+*
+*   Licensed under the Apache License, Version 2.0 (the "License");
+*   you may not use this file except in compliance with the License.
+*   You may obtain a copy of the License at
+*
+*       http://www.apache.org/licenses/LICENSE-2.0
+*
+*   Unless required by applicable law or agreed to in writing, software
+*   distributed under the License is distributed on an "AS IS" BASIS,
+*   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*   See the License for the specific language governing permissions and
+*   limitations under the License.
+*
+ */
+
+package database
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-openapi/runtime"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"softwareag.com/client"
+	"softwareag.com/models"
+)
+
+// fileSpace allocated and used blocks of a database file
+type fileSpace struct {
+	file       int64
+	name       string
+	ac         int64
+	ds, dsUsed int64
+	ni, niUsed int64
+	ui, uiUsed int64
+}
+
+func (f *fileSpace) allocated() int64 {
+	return f.ac + f.ds + f.ni + f.ui
+}
+
+// fileSpaceOf evaluate the allocated and used blocks out of the FCB extents
+func fileSpaceOf(fcb *models.FcbFile) *fileSpace {
+	space := &fileSpace{file: fcb.Number, name: fcb.Name, ac: acBlocks(fcb.ACextents)}
+	var free int64
+	space.ds, free = extentBlocks(fcb.DSextents)
+	space.dsUsed = space.ds - free
+	space.ni, free = extentBlocks(fcb.NIextents)
+	space.niUsed = space.ni - free
+	space.ui, free = extentBlocks(fcb.UIextents)
+	space.uiUsed = space.ui - free
+	return space
+}
+
+// SpaceReport report the allocated and used blocks of all files and the free space of the database
+func SpaceReport(clientInstance *client.AdabasAdmin, dbid int, auth runtime.ClientAuthInfoWriter) error {
+	if dbid < 1 {
+		fmt.Println("Please add option -dbid Adabas database id")
+		return fmt.Errorf("Please add option -dbid Adabas database id")
+	}
+	files, err := getFiles(clientInstance, dbid, auth)
+	if err != nil {
+		return err
+	}
+	spaces := make([]*fileSpace, 0)
+	for _, f := range files {
+		fcb, ferr := getFcb(clientInstance, dbid, f.FileNr, auth)
+		if ferr != nil {
+			return ferr
+		}
+		space := fileSpaceOf(fcb)
+		space.file = f.FileNr
+		spaces = append(spaces, space)
+	}
+	sort.SliceStable(spaces, func(i, j int) bool {
+		return spaces[i].allocated() > spaces[j].allocated()
+	})
+
+	p := message.NewPrinter(language.English)
+
+	p.Printf("\nDatabase %03d file space in blocks:\n\n", dbid)
+	p.Println(" File Name               AC      DS alloc    DS used   NI alloc    NI used   UI alloc    UI used")
+	var total fileSpace
+	for _, s := range spaces {
+		p.Printf(" %4d %-16s %6d %11d %10d %10d %10d %10d %10d\n", s.file, s.name, s.ac, s.ds, s.dsUsed,
+			s.ni, s.niUsed, s.ui, s.uiUsed)
+		total.ac += s.ac
+		total.ds += s.ds
+		total.dsUsed += s.dsUsed
+		total.ni += s.ni
+		total.niUsed += s.niUsed
+		total.ui += s.ui
+		total.uiUsed += s.uiUsed
+	}
+	p.Printf(" %-21s %6d %11d %10d %10d %10d %10d %10d\n", "Total", total.ac, total.ds, total.dsUsed,
+		total.ni, total.niUsed, total.ui, total.uiUsed)
+
+	container, err := getContainer(clientInstance, dbid, auth)
+	if err != nil {
+		return err
+	}
+	p.Printf("\nDatabase %03d free space table:\n\n", dbid)
+	p.Println(" Type   First RABN   Last RABN     Blocks  Block size")
+	free := make(map[string]int64)
+	for _, f := range container.FreeSpaceTable {
+		blocks := f.LastRABN - f.FirstRABN + 1
+		free[strings.ToUpper(f.Type)] += blocks
+		p.Printf(" %-4s %12d %11d %10d %11d\n", f.Type, f.FirstRABN, f.LastRABN, blocks, f.BlockSize)
+	}
+	p.Println()
+	for _, t := range []string{"ASSO", "DATA"} {
+		p.Printf(" Free %s blocks: %d\n", t, free[t])
+	}
+	return nil
+}
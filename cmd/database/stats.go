@@ -21,16 +21,19 @@ package database
 
 import (
 	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/go-openapi/runtime"
 	"golang.org/x/text/language"
 	"golang.org/x/text/message"
 	"softwareag.com/client"
 	"softwareag.com/client/online"
+	"softwareag.com/models"
 )
 
-// Highwater High water statistics
-func Highwater(clientInstance *client.AdabasAdmin, dbid int, auth runtime.ClientAuthInfoWriter) error {
+// getHighWater read the high water marks of the database
+func getHighWater(clientInstance *client.AdabasAdmin, dbid int, auth runtime.ClientAuthInfoWriter) (*models.HWMHighWater, error) {
 	params := online.NewGetDatabaseHighWaterParams()
 	rfc3339 := true
 	params.Rfc3339 = &rfc3339
@@ -44,69 +47,78 @@ func Highwater(clientInstance *client.AdabasAdmin, dbid int, auth runtime.Client
 		default:
 			fmt.Println("Error:", err)
 		}
+		return nil, err
+	}
+	return resp.Payload.HighWater, nil
+}
+
+// Highwater High water statistics
+func Highwater(clientInstance *client.AdabasAdmin, dbid int, auth runtime.ClientAuthInfoWriter) error {
+	highWater, err := getHighWater(clientInstance, dbid, auth)
+	if err != nil {
 		return err
 	}
 
 	p := message.NewPrinter(language.English)
 
 	fmt.Println()
-	fmt.Printf("Database %d, startup at %s\n", dbid, resp.Payload.HighWater.NucleusStartTime)
+	fmt.Printf("Database %d, startup at %s\n", dbid, highWater.NucleusStartTime)
 	fmt.Println("High Water Mark:")
 	fmt.Println()
 	p.Printf("%-18s  %10s   %10s   %10s   %02s  %s\n", "Area/Entry", "Size", "In Use", "High Water", "%", "Date/Time")
-	p.Printf("%-18s  %10d   %10d   %10d   %02d  %s\n", "User Queue", resp.Payload.HighWater.UserQueueSize,
-		resp.Payload.HighWater.UserQueueHighWaterMark.Inuse, resp.Payload.HighWater.UserQueueHighWaterMark.High, 0,
-		resp.Payload.HighWater.UserQueueHighWaterMark.Time)
+	p.Printf("%-18s  %10d   %10d   %10d   %02d  %s\n", "User Queue", highWater.UserQueueSize,
+		highWater.UserQueueHighWaterMark.Inuse, highWater.UserQueueHighWaterMark.High, 0,
+		highWater.UserQueueHighWaterMark.Time)
 	p.Printf("%-18s  %10s   %10d   %10d   %02d  %s\n", "Command Queue", "-",
-		resp.Payload.HighWater.CommandQueueHighWaterMark.Inuse, resp.Payload.HighWater.CommandQueueHighWaterMark.High, 0,
-		resp.Payload.HighWater.CommandQueueHighWaterMark.Time)
+		highWater.CommandQueueHighWaterMark.Inuse, highWater.CommandQueueHighWaterMark.High, 0,
+		highWater.CommandQueueHighWaterMark.Time)
 	p.Printf("%-18s  %10s   %10d   %10d   %02d  %s\n", "Hold Queue", "-",
-		resp.Payload.HighWater.HoldQueueHighWaterMark.Inuse, resp.Payload.HighWater.HoldQueueHighWaterMark.High, 0,
-		resp.Payload.HighWater.HoldQueueHighWaterMark.Time)
-	p.Printf("%-18s  %10d   %10d   %10d   %02d  %s\n", "Client Queue", resp.Payload.HighWater.ClientQueueSize,
-		resp.Payload.HighWater.ClientQueueHighWaterMark.Inuse, resp.Payload.HighWater.ClientQueueHighWaterMark.High, 0,
-		resp.Payload.HighWater.ClientQueueHighWaterMark.Time)
+		highWater.HoldQueueHighWaterMark.Inuse, highWater.HoldQueueHighWaterMark.High, 0,
+		highWater.HoldQueueHighWaterMark.Time)
+	p.Printf("%-18s  %10d   %10d   %10d   %02d  %s\n", "Client Queue", highWater.ClientQueueSize,
+		highWater.ClientQueueHighWaterMark.Inuse, highWater.ClientQueueHighWaterMark.High, 0,
+		highWater.ClientQueueHighWaterMark.Time)
 	p.Printf("%-18s  %10s   %10d   %10d   %02d  %s\n", "HQ User Limit", "-",
-		resp.Payload.HighWater.HQUserLimitHighWaterMark.Inuse, resp.Payload.HighWater.HQUserLimitHighWaterMark.High, 0,
-		resp.Payload.HighWater.UserQueueHighWaterMark.Time)
-	p.Printf("%-18s  %10d   %10d   %10d   %02d  %s\n", "Threads", resp.Payload.HighWater.ThreadSize,
-		resp.Payload.HighWater.ThreadsHighWaterMark.Inuse, resp.Payload.HighWater.ThreadsHighWaterMark.High, 0,
-		resp.Payload.HighWater.ThreadsHighWaterMark.Time)
-	p.Printf("%-18s  %10d   %10d   %10d   %02d  %s\n", "Workpool", resp.Payload.HighWater.WorkpoolSize,
-		resp.Payload.HighWater.WorkpoolHighWaterMark.Inuse, resp.Payload.HighWater.WorkpoolHighWaterMark.High, 0,
-		resp.Payload.HighWater.WorkpoolHighWaterMark.Time)
-	p.Printf("%-18s  %10d   %10d   %10d   %02d  %s\n", "  ISN Sort", resp.Payload.HighWater.SortAreaSize,
-		resp.Payload.HighWater.IsnSortHighWaterMark.Inuse, resp.Payload.HighWater.IsnSortHighWaterMark.High, 0,
-		resp.Payload.HighWater.IsnSortHighWaterMark.Time)
-	p.Printf("%-18s  %10d   %10d   %10d   %02d  %s\n", "  Complex Search", resp.Payload.HighWater.SortAreaSize,
-		resp.Payload.HighWater.ComplexSearchHighWaterMark.Inuse, resp.Payload.HighWater.ComplexSearchHighWaterMark.High, 0,
-		resp.Payload.HighWater.ComplexSearchHighWaterMark.Time)
-	p.Printf("%-18s  %10d   %10d   %10d   %02d  %s\n", "Attached Buffer", resp.Payload.HighWater.AttachedBufferSize,
-		resp.Payload.HighWater.AttachedBufferHighWaterMark.Inuse, resp.Payload.HighWater.AttachedBufferHighWaterMark.High, 0,
-		resp.Payload.HighWater.AttachedBufferHighWaterMark.Time)
-	p.Printf("%-18s  %10d   %10d   %10d   %02d  %s\n", "ATBX (MB)", resp.Payload.HighWater.LABXSize,
-		resp.Payload.HighWater.LABXHighWaterMark.Inuse, resp.Payload.HighWater.LABXHighWaterMark.High, 0,
-		resp.Payload.HighWater.LABXHighWaterMark.Time)
-	p.Printf("%-18s  %10d   %10d   %10d   %02d  %s\n", "Buffer Pool", resp.Payload.HighWater.BufferpoolSize,
-		resp.Payload.HighWater.BufferpoolHighWaterMark.Inuse, resp.Payload.HighWater.BufferpoolHighWaterMark.High, 0,
-		resp.Payload.HighWater.BufferpoolHighWaterMark.Time)
-	p.Printf("%-18s  %10d   %10d   %10d   %02d  %s\n", "Protection Area", resp.Payload.HighWater.ProtectionAreaSize,
-		resp.Payload.HighWater.WorkpoolHighWaterMark.Inuse, resp.Payload.HighWater.WorkpoolHighWaterMark.High, 0,
-		resp.Payload.HighWater.WorkpoolHighWaterMark.Time)
-	p.Printf("%-18s  %10d   %10d   %10d   %02d  %s\n", "  Active Area", resp.Payload.HighWater.ProtectionAreaActiveSize,
-		resp.Payload.HighWater.ProtectionAreaActiveHighWaterMark.Inuse, resp.Payload.HighWater.ProtectionAreaActiveHighWaterMark.High, 0,
-		resp.Payload.HighWater.ProtectionAreaActiveHighWaterMark.Time)
-	p.Printf("%-18s  %10d   %10d   %10d   %02d  %s\n", "Group Commit", resp.Payload.HighWater.GroupCommitSize,
-		resp.Payload.HighWater.GroupCommitHighWaterMark.Inuse, resp.Payload.HighWater.GroupCommitHighWaterMark.High, 0,
-		resp.Payload.HighWater.GroupCommitHighWaterMark.Time)
-	p.Printf("%-18s  %10d   %10d   %10d   %02d  %s\n", "Transaction Commit", resp.Payload.HighWater.TransactionTimeSize,
-		resp.Payload.HighWater.TransactionTimeHighWaterMark.Inuse, resp.Payload.HighWater.TransactionTimeHighWaterMark.High, 0,
-		resp.Payload.HighWater.TransactionTimeHighWaterMark.Time)
+		highWater.HQUserLimitHighWaterMark.Inuse, highWater.HQUserLimitHighWaterMark.High, 0,
+		highWater.UserQueueHighWaterMark.Time)
+	p.Printf("%-18s  %10d   %10d   %10d   %02d  %s\n", "Threads", highWater.ThreadSize,
+		highWater.ThreadsHighWaterMark.Inuse, highWater.ThreadsHighWaterMark.High, 0,
+		highWater.ThreadsHighWaterMark.Time)
+	p.Printf("%-18s  %10d   %10d   %10d   %02d  %s\n", "Workpool", highWater.WorkpoolSize,
+		highWater.WorkpoolHighWaterMark.Inuse, highWater.WorkpoolHighWaterMark.High, 0,
+		highWater.WorkpoolHighWaterMark.Time)
+	p.Printf("%-18s  %10d   %10d   %10d   %02d  %s\n", "  ISN Sort", highWater.SortAreaSize,
+		highWater.IsnSortHighWaterMark.Inuse, highWater.IsnSortHighWaterMark.High, 0,
+		highWater.IsnSortHighWaterMark.Time)
+	p.Printf("%-18s  %10d   %10d   %10d   %02d  %s\n", "  Complex Search", highWater.SortAreaSize,
+		highWater.ComplexSearchHighWaterMark.Inuse, highWater.ComplexSearchHighWaterMark.High, 0,
+		highWater.ComplexSearchHighWaterMark.Time)
+	p.Printf("%-18s  %10d   %10d   %10d   %02d  %s\n", "Attached Buffer", highWater.AttachedBufferSize,
+		highWater.AttachedBufferHighWaterMark.Inuse, highWater.AttachedBufferHighWaterMark.High, 0,
+		highWater.AttachedBufferHighWaterMark.Time)
+	p.Printf("%-18s  %10d   %10d   %10d   %02d  %s\n", "ATBX (MB)", highWater.LABXSize,
+		highWater.LABXHighWaterMark.Inuse, highWater.LABXHighWaterMark.High, 0,
+		highWater.LABXHighWaterMark.Time)
+	p.Printf("%-18s  %10d   %10d   %10d   %02d  %s\n", "Buffer Pool", highWater.BufferpoolSize,
+		highWater.BufferpoolHighWaterMark.Inuse, highWater.BufferpoolHighWaterMark.High, 0,
+		highWater.BufferpoolHighWaterMark.Time)
+	p.Printf("%-18s  %10d   %10d   %10d   %02d  %s\n", "Protection Area", highWater.ProtectionAreaSize,
+		highWater.WorkpoolHighWaterMark.Inuse, highWater.WorkpoolHighWaterMark.High, 0,
+		highWater.WorkpoolHighWaterMark.Time)
+	p.Printf("%-18s  %10d   %10d   %10d   %02d  %s\n", "  Active Area", highWater.ProtectionAreaActiveSize,
+		highWater.ProtectionAreaActiveHighWaterMark.Inuse, highWater.ProtectionAreaActiveHighWaterMark.High, 0,
+		highWater.ProtectionAreaActiveHighWaterMark.Time)
+	p.Printf("%-18s  %10d   %10d   %10d   %02d  %s\n", "Group Commit", highWater.GroupCommitSize,
+		highWater.GroupCommitHighWaterMark.Inuse, highWater.GroupCommitHighWaterMark.High, 0,
+		highWater.GroupCommitHighWaterMark.Time)
+	p.Printf("%-18s  %10d   %10d   %10d   %02d  %s\n", "Transaction Commit", highWater.TransactionTimeSize,
+		highWater.TransactionTimeHighWaterMark.Inuse, highWater.TransactionTimeHighWaterMark.High, 0,
+		highWater.TransactionTimeHighWaterMark.Time)
 	return nil
 }
 
-// CommandStats command statistics
-func CommandStats(clientInstance *client.AdabasAdmin, dbid int, auth runtime.ClientAuthInfoWriter) error {
+// getCommandStats read the command statistics of the database
+func getCommandStats(clientInstance *client.AdabasAdmin, dbid int, auth runtime.ClientAuthInfoWriter) ([]*models.CommandItems, error) {
 	params := online.NewGetDatabaseCommandStatsParams()
 	params.Dbid = float64(dbid)
 	resp, err := clientInstance.Online.GetDatabaseCommandStats(params, auth)
@@ -118,12 +130,24 @@ func CommandStats(clientInstance *client.AdabasAdmin, dbid int, auth runtime.Cli
 		default:
 			fmt.Println("Error:", err)
 		}
+		return nil, err
+	}
+	if resp.Payload.CommandStats == nil {
+		return []*models.CommandItems{}, nil
+	}
+	return resp.Payload.CommandStats.Commands, nil
+}
+
+// CommandStats command statistics
+func CommandStats(clientInstance *client.AdabasAdmin, dbid int, auth runtime.ClientAuthInfoWriter) error {
+	commands, err := getCommandStats(clientInstance, dbid, auth)
+	if err != nil {
 		return err
 	}
 
 	fmt.Println()
 	fmt.Println(" Adabas command statistics:")
-	for i, c := range resp.Payload.CommandStats.Commands {
+	for i, c := range commands {
 		if i%3 == 0 {
 			fmt.Println()
 		}
@@ -133,53 +157,92 @@ func CommandStats(clientInstance *client.AdabasAdmin, dbid int, auth runtime.Cli
 	return nil
 }
 
-// BufferpoolStats buffer pool statistics
-func BufferpoolStats(clientInstance *client.AdabasAdmin, dbid int, auth runtime.ClientAuthInfoWriter) error {
-	params := online.NewGetDatabaseBPStatsParams()
-	params.Dbid = float64(dbid)
-	resp, err := clientInstance.Online.GetDatabaseBPStats(params, auth)
+// BufferpoolStats buffer pool statistics. A sample is stored in the local history to recommend the
+// LBP value using the hit rate trend, the parameter defines the target hit rate and collect=false
+// only reports, like target=98,collect=false.
+func BufferpoolStats(clientInstance *client.AdabasAdmin, dbid int, param string, auth runtime.ClientAuthInfoWriter) error {
+	values, err := parseKeyValues(param, "target", "collect")
 	if err != nil {
-		switch err.(type) {
-		case *online.GetDatabaseBPStatsBadRequest:
-			response := err.(*online.GetDatabaseBPStatsBadRequest)
-			fmt.Println(response.Payload.Error.Code, ":", response.Payload.Error.Message)
-		default:
-			fmt.Println("Error:", err)
+		fmt.Println(err.Error())
+		return err
+	}
+	target := 95.0
+	if t, ok := values["target"]; ok {
+		target, err = strconv.ParseFloat(t, 64)
+		if err != nil || target <= 0 || target > 100 {
+			fmt.Println("Parameter target need hit rate percentage")
+			return fmt.Errorf("Parameter target need hit rate percentage")
 		}
+	}
+	collect := true
+	if c, ok := values["collect"]; ok {
+		collect, err = strconv.ParseBool(c)
+		if err != nil {
+			fmt.Println("Parameter collect need true or false")
+			return fmt.Errorf("Parameter collect need true or false")
+		}
+	}
+	stats, err := getBufferpoolStats(clientInstance, dbid, auth)
+	if err != nil {
 		return err
 	}
+	if collect {
+		err = appendHistory(bufferpoolHistory, historyServer(clientInstance), dbid, &bufferpoolSample{Time: time.Now(), Size: stats.Size,
+			Highwater: stats.AllocHighwater, LogicalReads: stats.IOLogicalReads, PhysicalReads: stats.IOPhysicalsReads})
+		if err != nil {
+			return err
+		}
+	}
 
 	p := message.NewPrinter(language.English)
 
 	fmt.Println()
 	fmt.Println(" Adabas buffer pool statistics:")
 	fmt.Println()
-	p.Printf(" Buffer Pool Size    :  %8d\n", resp.Payload.Statistics.Size)
+	p.Printf(" Buffer Pool Size    :  %8d\n", stats.Size)
 	fmt.Println()
+	rabns := stats.RabnsAsso + stats.RabnsData + stats.RabnsWork + stats.RabnsNucTmp + stats.RabnsNucSort
 	fmt.Println(" Pool Allocation                        RABNs present")
 	fmt.Println(" ---------------                        -------------")
-	percent := resp.Payload.Statistics.AllocCurrent * 100 / resp.Payload.Statistics.Size
-	p.Printf(" Current     (%3d%%) :  %12d     ASSO               : %12d\n", percent, resp.Payload.Statistics.AllocCurrent, resp.Payload.Statistics.RabnsAsso)
-	percent = resp.Payload.Statistics.AllocHighwater * 100 / resp.Payload.Statistics.Size
-	p.Printf(" Highwater   (%3d%%) :  %12d     DATA               : %12d\n", percent, resp.Payload.Statistics.AllocHighwater, resp.Payload.Statistics.RabnsData)
-	percent = resp.Payload.Statistics.AllocInternal * 100 / resp.Payload.Statistics.Size
-	p.Printf(" Internal    (%3d%%) :  %12d     WORK               : %12d\n", percent, resp.Payload.Statistics.AllocInternal, resp.Payload.Statistics.RabnsWork)
-	percent = resp.Payload.Statistics.AllocWorkpool * 100 / resp.Payload.Statistics.Size
-	p.Printf(" Workpool    (%3d%%) :  %12d     NUCTMP             : %12d\n", percent, resp.Payload.Statistics.AllocWorkpool, resp.Payload.Statistics.RabnsNucTmp)
-	p.Printf("                                        NUCSRT             : %12d\n", resp.Payload.Statistics.RabnsNucSort)
+	p.Printf(" Current     (%3.0f%%) :  %12d     ASSO       (%3.0f%%) : %12d\n", percentOf(stats.AllocCurrent, stats.Size),
+		stats.AllocCurrent, percentOf(stats.RabnsAsso, rabns), stats.RabnsAsso)
+	p.Printf(" Highwater   (%3.0f%%) :  %12d     DATA       (%3.0f%%) : %12d\n", percentOf(stats.AllocHighwater, stats.Size),
+		stats.AllocHighwater, percentOf(stats.RabnsData, rabns), stats.RabnsData)
+	p.Printf(" Internal    (%3.0f%%) :  %12d     WORK       (%3.0f%%) : %12d\n", percentOf(stats.AllocInternal, stats.Size),
+		stats.AllocInternal, percentOf(stats.RabnsWork, rabns), stats.RabnsWork)
+	p.Printf(" Workpool    (%3.0f%%) :  %12d     NUCTMP     (%3.0f%%) : %12d\n", percentOf(stats.AllocWorkpool, stats.Size),
+		stats.AllocWorkpool, percentOf(stats.RabnsNucTmp, rabns), stats.RabnsNucTmp)
+	p.Printf("                                        NUCSRT     (%3.0f%%) : %12d\n", percentOf(stats.RabnsNucSort, rabns),
+		stats.RabnsNucSort)
 	p.Printf("\n")
 	p.Printf(" I/O Statistics                         Buffer Flushes\n")
 	p.Printf(" --------------                         --------------\n")
-	p.Printf(" Logical Reads      :  %12d     Total              : %12d\n", resp.Payload.Statistics.IOLogicalReads, resp.Payload.Statistics.FlushesTotal)
-	p.Printf(" Physical Reads     :  %12d     To Free Space      : %12d\n", resp.Payload.Statistics.IOPhysicalsReads, resp.Payload.Statistics.FlushesFree)
-	phitrate := float64(resp.Payload.Statistics.IOLogicalReads-resp.Payload.Statistics.IOPhysicalsReads) / float64(resp.Payload.Statistics.IOLogicalReads) * 100
-	p.Printf(" Pool Hit Rate      :            %.1f%%  Temporary Blocks   : %12d\n", phitrate, 0)
-
-	p.Printf("                                        Write Limit  ( 50%%): %12d\n", resp.Payload.Statistics.WriteLimit)
-	p.Printf(" Physical Writes    :  %12d     Modified     (  0%%): %12d\n", resp.Payload.Statistics.IOPhysicalWrites, resp.Payload.Statistics.Modified)
+	p.Printf(" Logical Reads      :  %12d     Total              : %12d\n", stats.IOLogicalReads, stats.FlushesTotal)
+	p.Printf(" Physical Reads     :  %12d     To Free Space      : %12d\n", stats.IOPhysicalsReads, stats.FlushesFree)
+	hit := "-"
+	if rate, ok := hitRate(stats.IOLogicalReads, stats.IOPhysicalsReads); ok {
+		hit = fmt.Sprintf("%.1f%%", rate)
+	}
+	p.Printf(" Pool Hit Rate      :  %12s     Temporary Blocks   : %12d\n", hit, stats.RabnsNucTmp+stats.RabnsNucSort)
+	p.Printf("                                        Write Limit  (%3.0f%%): %12d\n", percentOf(stats.WriteLimit, stats.Size),
+		stats.WriteLimit)
+	p.Printf(" Physical Writes    :  %12d     Modified     (%3.0f%%): %12d\n", stats.IOPhysicalWrites,
+		percentOf(stats.Modified, stats.Size), stats.Modified)
+	fmt.Println()
 
-	//fmt.Printf("                                        Limit Temp.B.( 50%%): %12d\n", 0)
-	//fmt.Printf("                                        Modified T.B.(  0%%): %12d\n", 0)
+	samples, err := readBufferpoolSamples(historyServer(clientInstance), dbid)
+	if err != nil {
+		return err
+	}
+	points := bufferpoolHitRates(samples, stats.Size)
+	recommended, reason := adviseLBP(stats.Size, stats.AllocHighwater, points, target)
+	fmt.Println(" LBP sizing advisor:")
+	p.Printf("  %d hit rate intervals, %s\n", len(points), reason)
+	if recommended != stats.Size {
+		p.Printf("  Recommended LBP=%d (current %d)\n", recommended, stats.Size)
+	} else {
+		p.Printf("  Keep LBP=%d\n", stats.Size)
+	}
 	fmt.Println()
 	return nil
 }
@@ -0,0 +1,205 @@
+/*
+* Copyright © 2018 Software AG, Darmstadt, Germany and/or its licensors
+*
+* SPDX-License-Identifier: Apache-2.0
+*
+*   Licensed under the Apache License, Version 2.0 (the "License");
+*   you may not use this file except in compliance with the License.
+*   You may obtain a copy of the License at
+*
+*       http://www.apache.org/licenses/LICENSE-2.0
+*
+*   Unless required by applicable law or agreed to in writing, software
+*   distributed under the License is distributed on an "AS IS" BASIS,
+*   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*   See the License for the specific language governing permissions and
+*   limitations under the License.
+*
+ */
+
+package database
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-openapi/runtime"
+	"softwareag.com/client"
+	"softwareag.com/models"
+)
+
+// transactionRisk risk of a user to exceed the transaction time or non-activity limit
+type transactionRisk struct {
+	entry       *models.UserQueueEntry
+	detail      *models.UserQueueDetail
+	transaction time.Duration
+	idle        time.Duration
+	ttLimit     time.Duration
+	tnaLimit    time.Duration
+	risk        float64
+	reason      string
+}
+
+// tnaLimits database non-activity limits of access only, ET and exclusive users
+type tnaLimits struct {
+	access    time.Duration
+	et        time.Duration
+	exclusive time.Duration
+}
+
+// userType type of the user given in the flags of the user queue entry, EXU, ET or ACC
+func userType(entry *models.UserQueueEntry) string {
+	flags := strings.FieldsFunc(strings.ToUpper(entry.Flags), func(r rune) bool {
+		return (r < 'A' || r > 'Z') && (r < '0' || r > '9')
+	})
+	for _, f := range flags {
+		if f == "EXU" || f == "EXF" {
+			return "EXU"
+		}
+	}
+	for _, f := range flags {
+		if f == "ET" {
+			return "ET"
+		}
+	}
+	return "ACC"
+}
+
+// limit non-activity limit and its parameter name for the type of the user
+func (l *tnaLimits) limit(entry *models.UserQueueEntry) (time.Duration, string) {
+	switch userType(entry) {
+	case "EXU":
+		return l.exclusive, "TNAX"
+	case "ET":
+		return l.et, "TNAE"
+	}
+	return l.access, "TNAA"
+}
+
+// transactionRisks evaluate the percentage of the TT and TNA limit used by each user. Users without
+// own limits use the database limits of their user type. The result is sorted by the highest risk.
+func transactionRisks(users []*models.UserQueueEntry, details map[int64]*models.UserQueueDetail, now time.Time,
+	tt time.Duration, tna *tnaLimits) []*transactionRisk {
+	risks := make([]*transactionRisk, 0)
+	for _, u := range users {
+		d := details[u.UqID]
+		if d == nil {
+			continue
+		}
+		tnaLimit, tnaName := tna.limit(u)
+		r := &transactionRisk{entry: u, detail: d, ttLimit: tt, tnaLimit: tnaLimit}
+		if d.TTLimit > 0 {
+			r.ttLimit = time.Duration(d.TTLimit) * time.Second
+		}
+		if d.TNALimit > 0 {
+			r.tnaLimit = time.Duration(d.TNALimit) * time.Second
+			tnaName = "TNA"
+		}
+		if !time.Time(d.LastActivity).IsZero() {
+			r.idle = now.Sub(time.Time(d.LastActivity))
+			if r.tnaLimit > 0 {
+				r.risk = float64(r.idle) * 100 / float64(r.tnaLimit)
+				r.reason = tnaName
+			}
+		}
+		if openTransaction(d) {
+			r.transaction = now.Sub(time.Time(d.StartTransaction))
+			if r.ttLimit > 0 {
+				if risk := float64(r.transaction) * 100 / float64(r.ttLimit); risk >= r.risk {
+					r.risk = risk
+					r.reason = "TT"
+				}
+			}
+		}
+		risks = append(risks, r)
+	}
+	sort.SliceStable(risks, func(i, j int) bool { return risks[i].risk > risks[j].risk })
+	return risks
+}
+
+// exceededLimit limit the risk is evaluated against
+func (r *transactionRisk) exceededLimit() time.Duration {
+	if r.reason == "TT" {
+		return r.ttLimit
+	}
+	return r.tnaLimit
+}
+
+// Transactions list users whose open transaction or inactivity approach the TT or TNA limits. The
+// parameter threshold=<percent> defines the minimum risk shown, default is 50%. With stop=<percent>
+// all users exceeding the percentage are stopped, used together with -repeat as monitor.
+func Transactions(clientInstance *client.AdabasAdmin, dbid int, param string, auth runtime.ClientAuthInfoWriter) error {
+	if dbid < 1 {
+		fmt.Println("Please add option -dbid Adabas database id")
+		return fmt.Errorf("Please add option -dbid Adabas database id")
+	}
+	values, err := parseKeyValues(param, "threshold", "stop")
+	if err != nil {
+		fmt.Println(err.Error())
+		return err
+	}
+	threshold := 50.0
+	stop := 0.0
+	for k, v := range values {
+		f, perr := strconv.ParseFloat(v, 64)
+		if perr != nil || f <= 0 {
+			fmt.Printf("Parameter %s=%s not valid, need percentage\n", k, v)
+			return fmt.Errorf("Parameter %s=%s not valid, need percentage", k, v)
+		}
+		if k == "threshold" {
+			threshold = f
+		} else {
+			stop = f
+		}
+	}
+	parameter, err := getParameter(clientInstance, dbid, "dynamic", auth)
+	if err != nil {
+		return err
+	}
+	users, details, err := userDetails(clientInstance, dbid, auth)
+	if err != nil {
+		return err
+	}
+	tna := &tnaLimits{access: time.Duration(parameter.TNAA) * time.Second, et: time.Duration(parameter.TNAE) * time.Second,
+		exclusive: time.Duration(parameter.TNAX) * time.Second}
+	risks := transactionRisks(users, details, time.Now(), time.Duration(parameter.TT)*time.Second, tna)
+
+	fmt.Printf("\nTransactions of database %03d with TT=%ds TNAA=%ds TNAE=%ds TNAX=%ds, risk above %.0f%%:\n\n", dbid,
+		parameter.TT, parameter.TNAA, parameter.TNAE, parameter.TNAX, threshold)
+	fmt.Printf(" %4s %-8s %-8s %-8s %10s %10s %8s %-6s %8s %7s\n", "Id", "User", "Node", "Terminal", "Trans", "Idle",
+		"ISN Hold", "Reason", "Limit", "Risk")
+	shown := 0
+	for _, r := range risks {
+		if r.risk < threshold {
+			continue
+		}
+		shown++
+		node, terminal := "", ""
+		if r.entry.UID != nil {
+			node, terminal = r.entry.UID.Node, r.entry.UID.Terminal
+		}
+		transaction := "-"
+		if openTransaction(r.detail) {
+			transaction = r.transaction.Round(time.Second).String()
+		}
+		fmt.Printf(" %4d %-8s %-8s %-8s %10s %10v %8d %-6s %8v %6.0f%%\n", r.entry.UqID, r.entry.User, node, terminal,
+			transaction, r.idle.Round(time.Second), r.detail.ISNHold, r.reason, r.exceededLimit(), r.risk)
+	}
+	if shown == 0 {
+		fmt.Println(" No user above the threshold")
+	}
+	for _, r := range risks {
+		if stop <= 0 || r.risk < stop {
+			continue
+		}
+		fmt.Printf("Stop user %d exceeding %.0f%% of the %s limit\n", r.entry.UqID, stop, r.reason)
+		if _, err = stopUserIfPresent(clientInstance, dbid, r.entry.UqID, auth); err != nil {
+			return err
+		}
+	}
+	fmt.Println()
+	return nil
+}
@@ -0,0 +1,230 @@
+/*
+* Copyright © 2018 Software AG, Darmstadt, Germany and/or its licensors
+*
+* SPDX-License-Identifier: Apache-2.0
+*
+*   Licensed under the Apache License, Version 2.0 (the "License");
+*   you may not use this file except in compliance with the License.
+*   You may obtain a copy of the License at
+*
+*       http://www.apache.org/licenses/LICENSE-2.0
+*
+*   Unless required by applicable law or agreed to in writing, software
+*   distributed under the License is distributed on an "AS IS" BASIS,
+*   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*   See the License for the specific language governing permissions and
+*   limitations under the License.
+*
+ */
+
+package database
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-openapi/runtime"
+	"softwareag.com/client"
+	"softwareag.com/client/online_offline"
+	"softwareag.com/models"
+)
+
+// ucbFilter filter of the UCB entries by utility, mode, file and age
+type ucbFilter struct {
+	utility string
+	mode    string
+	file    int64
+	age     time.Duration
+}
+
+// parseUcbFilter parse the UCB filter like utility=ADAORD,age=7 with the age in days
+func parseUcbFilter(param string, extra ...string) (*ucbFilter, map[string]string, error) {
+	values, err := parseKeyValues(param, append([]string{"utility", "mode", "file", "age"}, extra...)...)
+	if err != nil {
+		return nil, nil, err
+	}
+	filter := &ucbFilter{utility: values["utility"], mode: values["mode"]}
+	if v, ok := values["file"]; ok {
+		filter.file, err = strconv.ParseInt(v, 10, 64)
+		if err != nil || filter.file < 1 {
+			return nil, nil, fmt.Errorf("Filter file=%s not valid, need file number", v)
+		}
+	}
+	if v, ok := values["age"]; ok {
+		days, err := strconv.ParseFloat(v, 64)
+		if err != nil || days < 0 {
+			return nil, nil, fmt.Errorf("Filter age=%s not valid, need number of days", v)
+		}
+		filter.age = time.Duration(days * 24 * float64(time.Hour))
+	}
+	return filter, values, nil
+}
+
+// empty check if no filter is defined
+func (filter *ucbFilter) empty() bool {
+	return filter.utility == "" && filter.mode == "" && filter.file == 0 && filter.age == 0
+}
+
+// matches check if the UCB entry matches the filter, entries without date never match an age
+func (filter *ucbFilter) matches(entry *models.UCBEntry, now time.Time) bool {
+	if !matchName(filter.utility, entry.ID) || !matchName(filter.mode, entry.DBMode) {
+		return false
+	}
+	if filter.file > 0 {
+		found := false
+		for _, f := range entry.UcbFiles {
+			if f.UcbFile == filter.file {
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if filter.age > 0 {
+		date := time.Time(entry.Date)
+		if date.IsZero() || now.Sub(date) < filter.age {
+			return false
+		}
+	}
+	return true
+}
+
+// ucbFileList list of the UCB files with consecutive files as range, like 1,3-5,9
+func ucbFileList(entry *models.UCBEntry) string {
+	list := make([]string, 0)
+	for i := 0; i < len(entry.UcbFiles); i++ {
+		first := entry.UcbFiles[i].UcbFile
+		last := first
+		for i+1 < len(entry.UcbFiles) && entry.UcbFiles[i+1].UcbFile == last+1 {
+			i++
+			last++
+		}
+		if last > first {
+			list = append(list, fmt.Sprintf("%d-%d", first, last))
+		} else {
+			list = append(list, strconv.FormatInt(first, 10))
+		}
+	}
+	if len(list) == 0 {
+		return "-"
+	}
+	return strings.Join(list, ",")
+}
+
+// getUcb read the UCB entries of the database
+func getUcb(clientInstance *client.AdabasAdmin, dbid int, auth runtime.ClientAuthInfoWriter) ([]*models.UCBEntry, error) {
+	params := online_offline.NewGetUCBParams()
+	rfc3339 := true
+	params.Rfc3339 = &rfc3339
+	params.Dbid = float64(dbid)
+
+	resp, err := clientInstance.OnlineOffline.GetUCB(params, auth)
+	if err != nil {
+		switch err.(type) {
+		case *online_offline.GetUCBBadRequest:
+			response := err.(*online_offline.GetUCBBadRequest)
+			fmt.Println(response.Payload.Error.Code, ":", response.Payload.Error.Message)
+		default:
+			fmt.Println("Error:", err)
+		}
+		return nil, err
+	}
+	if resp.Payload.UCB == nil {
+		return []*models.UCBEntry{}, nil
+	}
+	return resp.Payload.UCB.UCB, nil
+}
+
+// filterUcb read the UCB entries matching the filter
+func filterUcb(clientInstance *client.AdabasAdmin, dbid int, filter *ucbFilter, auth runtime.ClientAuthInfoWriter) ([]*models.UCBEntry, error) {
+	entries, err := getUcb(clientInstance, dbid, auth)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	matching := make([]*models.UCBEntry, 0)
+	for _, e := range entries {
+		if filter.matches(e, now) {
+			matching = append(matching, e)
+		}
+	}
+	return matching, nil
+}
+
+// printUcb print the UCB entries with the files
+func printUcb(entries []*models.UCBEntry) {
+	fmt.Println()
+	fmt.Println(" UCB entries:")
+	fmt.Println()
+	fmt.Printf(" %-20s %-10s %-8s %-8s %-8s\n", "Date/Time", "Entry ID", "Utility", "Mode", "Files")
+	for _, c := range entries {
+		fmt.Printf(" %-20s %-10d %-8s %-8s %s\n", time.Time(c.Date).Format("2006-01-02 15:04:05"), c.Sequence,
+			c.ID, c.DBMode, ucbFileList(c))
+	}
+}
+
+// deleteUcb delete the UCB entry and return the Adabas status
+func deleteUcb(clientInstance *client.AdabasAdmin, dbid int, ucbid int64, auth runtime.ClientAuthInfoWriter) (string, error) {
+	params := online_offline.NewDeleteUCBParams()
+	params.Dbid = float64(dbid)
+	params.Ucbid = ucbid
+
+	resp, err := clientInstance.OnlineOffline.DeleteUCB(params, auth)
+	if err != nil {
+		switch err.(type) {
+		case *online_offline.DeleteUCBBadRequest:
+			response := err.(*online_offline.DeleteUCBBadRequest)
+			fmt.Println(response.Payload.Error.Code, ":", response.Payload.Error.Message)
+		default:
+			fmt.Println("Error:", err)
+		}
+		return "", err
+	}
+	return resp.Payload.Status.Message, nil
+}
+
+// PruneUcb delete all UCB entries matching the filter after confirmation, like utility=ADAORD,age=7.
+// With confirm=yes no confirmation is requested.
+func PruneUcb(clientInstance *client.AdabasAdmin, dbid int, param string, auth runtime.ClientAuthInfoWriter) error {
+	filter, values, err := parseUcbFilter(param, "confirm")
+	if err != nil {
+		fmt.Println(err.Error())
+		return err
+	}
+	if filter.empty() {
+		fmt.Println("Please add filter like utility=ADAORD,age=7 of the UCB entries to be deleted")
+		return fmt.Errorf("Please add filter of the UCB entries to be deleted")
+	}
+	entries, err := filterUcb(clientInstance, dbid, filter, auth)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		fmt.Println("No UCB entry matches the filter")
+		return nil
+	}
+	printUcb(entries)
+	fmt.Println()
+	if values["confirm"] != "yes" && !confirm(fmt.Sprintf("Delete %d UCB entries of database %03d?", len(entries), dbid)) {
+		fmt.Println("Delete of UCB entries cancelled")
+		return nil
+	}
+	deleted := 0
+	for _, e := range entries {
+		status, err := deleteUcb(clientInstance, dbid, e.Sequence, auth)
+		if err != nil {
+			fmt.Printf(" UCB entry %-10d %-8s failed\n", e.Sequence, e.ID)
+			continue
+		}
+		fmt.Printf(" UCB entry %-10d %-8s %s\n", e.Sequence, e.ID, status)
+		deleted++
+	}
+	fmt.Printf("Deleted %d of %d UCB entries\n", deleted, len(entries))
+	if deleted < len(entries) {
+		return fmt.Errorf("Delete of %d UCB entries failed", len(entries)-deleted)
+	}
+	return nil
+}
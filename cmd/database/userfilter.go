@@ -0,0 +1,218 @@
+/*
+* Copyright © 2018 Software AG, Darmstadt, Germany and/or its licensors
+*
+* SPDX-License-Identifier: Apache-2.0
+*
+*   Licensed under the Apache License, Version 2.0 (the "License");
+*   you may not use this file except in compliance with the License.
+*   You may obtain a copy of the License at
+*
+*       http://www.apache.org/licenses/LICENSE-2.0
+*
+*   Unless required by applicable law or agreed to in writing, software
+*   distributed under the License is distributed on an "AS IS" BASIS,
+*   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*   See the License for the specific language governing permissions and
+*   limitations under the License.
+*
+ */
+
+package database
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-openapi/runtime"
+	"softwareag.com/client"
+	"softwareag.com/models"
+)
+
+// userFilter filter of user queue entries
+type userFilter struct {
+	user     string
+	node     string
+	terminal string
+	idle     time.Duration
+	open     *bool
+	file     int64
+}
+
+// parseUserFilter parse the user queue filter like user=ADM*,node=host1,idle=600,open=true,file=11.
+// User, node and terminal accept a trailing * as wildcard, idle is the minimum inactivity in seconds.
+func parseUserFilter(param string, extra ...string) (*userFilter, map[string]string, error) {
+	values, err := parseKeyValues(param, append([]string{"user", "node", "terminal", "idle", "open", "file"}, extra...)...)
+	if err != nil {
+		return nil, nil, err
+	}
+	filter := &userFilter{user: values["user"], node: values["node"], terminal: values["terminal"]}
+	if v, ok := values["idle"]; ok {
+		seconds, err := strconv.Atoi(v)
+		if err != nil || seconds < 0 {
+			return nil, nil, fmt.Errorf("Filter idle=%s not valid, need number of seconds", v)
+		}
+		filter.idle = time.Duration(seconds) * time.Second
+	}
+	if v, ok := values["open"]; ok {
+		open := false
+		switch strings.ToLower(v) {
+		case "true", "yes":
+			open = true
+		case "false", "no":
+		default:
+			return nil, nil, fmt.Errorf("Filter open=%s not valid, need true or false", v)
+		}
+		filter.open = &open
+	}
+	if v, ok := values["file"]; ok {
+		filter.file, err = strconv.ParseInt(v, 10, 64)
+		if err != nil || filter.file < 1 {
+			return nil, nil, fmt.Errorf("Filter file=%s not valid, need file number", v)
+		}
+	}
+	return filter, values, nil
+}
+
+// empty check if no filter is defined
+func (filter *userFilter) empty() bool {
+	return filter.user == "" && filter.node == "" && filter.terminal == "" && !filter.needDetail()
+}
+
+// needDetail check if the filter need the user queue detail
+func (filter *userFilter) needDetail() bool {
+	return filter.idle > 0 || filter.open != nil || filter.file > 0
+}
+
+// matchName compare the value with the pattern, a trailing * matches any suffix
+func matchName(pattern, value string) bool {
+	if pattern == "" {
+		return true
+	}
+	pattern = strings.ToUpper(pattern)
+	value = strings.ToUpper(strings.TrimSpace(value))
+	if strings.HasSuffix(pattern, "*") {
+		return strings.HasPrefix(value, pattern[:len(pattern)-1])
+	}
+	return pattern == value
+}
+
+// matches check if the user queue entry and its detail match the filter
+func (filter *userFilter) matches(entry *models.UserQueueEntry, detail *models.UserQueueDetail, now time.Time) bool {
+	if !matchName(filter.user, entry.User) {
+		return false
+	}
+	if entry.UID != nil {
+		if !matchName(filter.node, entry.UID.Node) || !matchName(filter.terminal, entry.UID.Terminal) {
+			return false
+		}
+	} else if filter.node != "" || filter.terminal != "" {
+		return false
+	}
+	if !filter.needDetail() {
+		return true
+	}
+	if detail == nil {
+		return false
+	}
+	if filter.idle > 0 && now.Sub(time.Time(detail.LastActivity)) < filter.idle {
+		return false
+	}
+	if filter.open != nil && openTransaction(detail) != *filter.open {
+		return false
+	}
+	if filter.file > 0 {
+		found := false
+		for _, f := range detail.Files {
+			if f == filter.file {
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// filterUsers read the user queue entries matching the filter, users leaving the user queue
+// while their details are read are skipped
+func filterUsers(clientInstance *client.AdabasAdmin, dbid int, filter *userFilter,
+	auth runtime.ClientAuthInfoWriter) ([]*models.UserQueueEntry, map[int64]*models.UserQueueDetail, error) {
+	var users []*models.UserQueueEntry
+	details := make(map[int64]*models.UserQueueDetail)
+	var err error
+	if filter.needDetail() {
+		users, details, err = userDetails(clientInstance, dbid, auth)
+	} else {
+		users, err = getUserQueue(clientInstance, dbid, auth)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	selected := make([]*models.UserQueueEntry, 0)
+	now := time.Now()
+	for _, u := range users {
+		if filter.matches(u, details[u.UqID], now) {
+			selected = append(selected, u)
+		}
+	}
+	return selected, details, nil
+}
+
+// confirm ask the user for confirmation on the terminal
+func confirm(question string) bool {
+	fmt.Printf("%s (yes/no): ", question)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "yes" || answer == "y"
+}
+
+// stopUsers stop all users matching the filter after confirmation. With confirm=yes
+// no confirmation is requested.
+func stopUsers(clientInstance *client.AdabasAdmin, dbid int, param string, auth runtime.ClientAuthInfoWriter) error {
+	filter, values, err := parseUserFilter(param, "confirm")
+	if err != nil {
+		fmt.Println(err.Error())
+		return err
+	}
+	if filter.empty() {
+		fmt.Println("Please add user queue id or filter like user=ADM*,idle=600 of the users to be stopped")
+		return fmt.Errorf("Please add user queue id or filter of the users to be stopped")
+	}
+	users, _, err := filterUsers(clientInstance, dbid, filter, auth)
+	if err != nil {
+		return err
+	}
+	if len(users) == 0 {
+		fmt.Println("No user matches the filter")
+		return nil
+	}
+	printUserQueue(users)
+	fmt.Println()
+	if values["confirm"] != "yes" && !confirm(fmt.Sprintf("Stop %d users of database %03d?", len(users), dbid)) {
+		fmt.Println("Stop of users cancelled")
+		return nil
+	}
+	stopped, failed := 0, 0
+	for _, u := range users {
+		ok, err := stopUserIfPresent(clientInstance, dbid, u.UqID, auth)
+		switch {
+		case err != nil:
+			failed++
+		case ok:
+			stopped++
+		}
+	}
+	fmt.Printf("Stop of %d of %d users initiated\n", stopped, len(users))
+	if failed > 0 {
+		return fmt.Errorf("Stop of %d users failed", failed)
+	}
+	return nil
+}
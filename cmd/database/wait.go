@@ -0,0 +1,181 @@
+/*
+* Copyright © 2018 Software AG, Darmstadt, Germany and/or its licensors
+*
+* SPDX-License-Identifier: Apache-2.0
+*
+*   Licensed under the Apache License, Version 2.0 (the "License");
+*   you may not use this file except in compliance with the License.
+*   You may obtain a copy of the License at
+*
+*       http://www.apache.org/licenses/LICENSE-2.0
+*
+*   Unless required by applicable law or agreed to in writing, software
+*   distributed under the License is distributed on an "AS IS" BASIS,
+*   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*   See the License for the specific language governing permissions and
+*   limitations under the License.
+*
+ */
+
+package database
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-openapi/runtime"
+	"softwareag.com/client"
+	"softwareag.com/client/online_offline"
+)
+
+// ErrWaitTimeout returned if the database does not reach the expected state in time
+var ErrWaitTimeout = errors.New("Timeout waiting for database state")
+
+// waitInterval interval between two database status requests
+var waitInterval = 2 * time.Second
+
+// nucleusLogTail number of nucleus log lines shown if no error lines are found
+const nucleusLogTail = 20
+
+var nucleusLogErrors = []string{"ERROR", "ABNORMAL", "ABORT", "CANNOT", "FAILED", "FATAL"}
+
+// databaseActive check if the database is online
+func databaseActive(clientInstance *client.AdabasAdmin, dbid int, auth runtime.ClientAuthInfoWriter) (bool, error) {
+	resp, err := clientInstance.OnlineOffline.GetDatabases(nil, auth)
+	if err != nil {
+		switch err.(type) {
+		case *online_offline.GetDatabasesBadRequest:
+			response := err.(*online_offline.GetDatabasesBadRequest)
+			fmt.Println(response.Payload.Error.Code, ":", response.Payload.Error.Message)
+		default:
+			fmt.Println("Error:", err)
+		}
+		return false, err
+	}
+	for _, d := range resp.Payload.Database {
+		if int(d.Dbid) == dbid {
+			return d.Active, nil
+		}
+	}
+	fmt.Printf("Database %03d not found\n", dbid)
+	return false, fmt.Errorf("Database %03d not found", dbid)
+}
+
+// nucleusLogLines read the nucleus log of the database
+func nucleusLogLines(clientInstance *client.AdabasAdmin, dbid int, auth runtime.ClientAuthInfoWriter) ([]string, error) {
+	params := online_offline.NewGetDatabaseNucleusLogParams()
+	params.Dbid = float64(dbid)
+	resp, err := clientInstance.OnlineOffline.GetDatabaseNucleusLog(params, auth)
+	if err != nil {
+		switch err.(type) {
+		case *online_offline.GetDatabaseNucleusLogBadRequest:
+			response := err.(*online_offline.GetDatabaseNucleusLogBadRequest)
+			fmt.Println(response.Payload.Error.Code, ":", response.Payload.Error.Message)
+		default:
+			fmt.Println("Error:", err)
+		}
+		return nil, err
+	}
+	if resp.Payload.Log == nil {
+		return []string{}, nil
+	}
+	return strings.Split(strings.TrimRight(strings.Replace(resp.Payload.Log.Log, "\r", "", -1), "\n"), "\n"), nil
+}
+
+// nucleusErrors lines of the nucleus log reporting errors
+func nucleusErrors(lines []string) []string {
+	errorLines := make([]string, 0)
+	for _, l := range lines {
+		u := strings.ToUpper(l)
+		for _, e := range nucleusLogErrors {
+			if strings.Contains(u, e) {
+				errorLines = append(errorLines, l)
+				break
+			}
+		}
+	}
+	return errorLines
+}
+
+// printNucleusLog print the error lines of the nucleus log, or the end of the log if no error is found
+func printNucleusLog(clientInstance *client.AdabasAdmin, dbid int, auth runtime.ClientAuthInfoWriter) {
+	lines, err := nucleusLogLines(clientInstance, dbid, auth)
+	if err != nil {
+		return
+	}
+	errorLines := nucleusErrors(lines)
+	if len(errorLines) > 0 {
+		fmt.Printf("\nDatabase %03d nucleus log errors:\n", dbid)
+		lines = errorLines
+	} else {
+		fmt.Printf("\nDatabase %03d nucleus log:\n", dbid)
+		if len(lines) > nucleusLogTail {
+			lines = lines[len(lines)-nucleusLogTail:]
+		}
+	}
+	for _, l := range lines {
+		fmt.Println(" " + l)
+	}
+}
+
+// newNucleusErrors error lines of the nucleus log written after the first known lines. A log
+// shorter than known was restarted and is checked completely.
+func newNucleusErrors(lines []string, known int) []string {
+	if known > len(lines) {
+		known = 0
+	}
+	return nucleusErrors(lines[known:])
+}
+
+// waitState wait until the database is online or offline. Errors written to the nucleus log while
+// waiting end the wait early, they are shown and an error is returned. On timeout the nucleus log
+// is shown and ErrWaitTimeout is returned.
+func waitState(clientInstance *client.AdabasAdmin, dbid int, online bool, timeout time.Duration,
+	auth runtime.ClientAuthInfoWriter) error {
+	state := "offline"
+	if online {
+		state = "online"
+	}
+	fmt.Printf("Waiting up to %v for database %03d to be %s\n", timeout, dbid, state)
+	known := 0
+	if lines, err := nucleusLogLines(clientInstance, dbid, auth); err == nil {
+		known = len(lines)
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		active, err := databaseActive(clientInstance, dbid, auth)
+		if err != nil {
+			return err
+		}
+		if active == online {
+			fmt.Printf("Database %03d is %s\n", dbid, state)
+			return nil
+		}
+		if lines, lerr := nucleusLogLines(clientInstance, dbid, auth); lerr == nil {
+			if errorLines := newNucleusErrors(lines, known); len(errorLines) > 0 {
+				fmt.Printf("Database %03d not %s, nucleus log errors:\n", dbid, state)
+				for _, l := range errorLines {
+					fmt.Println(" " + l)
+				}
+				return fmt.Errorf("Database %03d not %s, nucleus reported errors", dbid, state)
+			}
+		}
+		if time.Now().After(deadline) {
+			fmt.Printf("Database %03d not %s after %v\n", dbid, state, timeout)
+			printNucleusLog(clientInstance, dbid, auth)
+			return ErrWaitTimeout
+		}
+		time.Sleep(waitInterval)
+	}
+}
+
+// WaitState wait until the database is online or offline, the timeout is given in seconds
+func WaitState(clientInstance *client.AdabasAdmin, dbid int, online bool, timeout int, auth runtime.ClientAuthInfoWriter) error {
+	if dbid < 1 {
+		fmt.Println("Please add option -dbid Adabas database id")
+		return fmt.Errorf("Please add option -dbid Adabas database id")
+	}
+	return waitState(clientInstance, dbid, online, time.Duration(timeout)*time.Second, auth)
+}